@@ -0,0 +1,22 @@
+package v1alpha1
+
+// HealthRule defines when resources of a group, version and kind are healthy.
+type HealthRule struct {
+	// Group of the resources the rule applies to, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version of the resources the rule applies to. Defaults to all versions.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Kind of the resources the rule applies to.
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+
+	// Expression is a CEL expression evaluating to true once a resource is healthy,
+	// with the resource available as self, e.g. self.status.phase == 'Running'.
+	// As long as the expression evaluates to false or cannot be evaluated, the resource is progressing.
+	// +kubebuilder:validation:MinLength=1
+	Expression string `json:"expression"`
+}
@@ -20,11 +20,42 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// +kubebuilder:object:root=true
+// ManagedSpec defines the desired state of Managed.
+type ManagedSpec struct {
+	// Resources are the resources installed by the owning Sample, see SampleStatus.Inventory.
+	// The Managed resource is kept in sync with the inventory of the Sample by the operator.
+	// +kubebuilder:validation:MaxItems=1000
+	// +optional
+	Resources []InventoryEntry `json:"resources,omitempty"`
+}
+
+// ManagedStatus defines the observed state of Managed.
+type ManagedStatus struct {
+	Status `json:",inline"`
+
+	// ResourcesAvailable summarizes the resources of the spec which exist as available/total.
+	// +optional
+	ResourcesAvailable string `json:"resourcesAvailable,omitempty"`
+
+	// MissingResources lists the resources of the spec which do not exist,
+	// limited to the first MaxResourceStatuses entries.
+	// +optional
+	MissingResources []InventoryEntry `json:"missingResources,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=".status.state"
+//+kubebuilder:printcolumn:name="Available",type=string,JSONPath=".status.resourcesAvailable"
 
+// Managed is created and owned by a Sample and reports whether the resources installed by the Sample exist.
+// It is deleted together with its Sample.
 type Managed struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagedSpec   `json:"spec,omitempty"`
+	Status ManagedStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -35,3 +66,8 @@ type ManagedList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 	Items           []Managed `json:"items"`
 }
+
+func (s *ManagedStatus) WithState(state State) *ManagedStatus {
+	s.State = state
+	return s
+}
@@ -22,6 +22,7 @@ package v1alpha1
 import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -36,8 +37,43 @@ var (
 	// GroupVersion is group version used to register these objects.
 	GroupVersion = schema.GroupVersion{Group: "operator.kyma-project.io", Version: "v1alpha1"}
 
-	ConditionTypeInstallation = "Installation"
-	ConditionReasonReady      = "Ready"
+	ConditionTypeInstallation                = "Installation"
+	ConditionReasonReady                     = "Ready"
+	ConditionReasonInstallationFailed        = "InstallationFailed"
+	ConditionReasonRenderFailed              = "RenderFailed"
+	ConditionReasonManifestNotFound          = "ManifestNotFound"
+	ConditionReasonManifestAmbiguous         = "ManifestAmbiguous"
+	ConditionReasonManifestUnsupportedFormat = "ManifestUnsupportedFormat"
+	ConditionReasonManifestParseError        = "ManifestParseError"
+	ConditionReasonManifestDigestMismatch    = "ManifestDigestMismatch"
+	ConditionReasonManifestTooLarge          = "ManifestTooLarge"
+	ConditionReasonDocumentsSkipped          = "DocumentsSkipped"
+	ConditionReasonResourcesNotReady         = "ResourcesNotReady"
+	ConditionReasonReadinessTimeout          = "ReadinessTimeout"
+	ConditionReasonResourcesDegraded         = "ResourcesDegraded"
+	ConditionReasonHealthRuleInvalid         = "HealthRuleInvalid"
+	ConditionReasonResourcesDeleting         = "ResourcesDeleting"
+	ConditionReasonSyncWaveInvalid           = "SyncWaveInvalid"
+	ConditionReasonHooksPending              = "HooksPending"
+	ConditionReasonHooksRunning              = "HooksRunning"
+	ConditionReasonHooksSucceeded            = "HooksSucceeded"
+	ConditionReasonHookFailed                = "HookFailed"
+	ConditionReasonHookTimeout               = "HookTimeout"
+	ConditionReasonHookInvalid               = "HookInvalid"
+	ConditionReasonBlockingResourcesExist    = "BlockingResourcesExist"
+
+	// The conditions of hooks report the result of the hooks of the manifest which ran last in their phase.
+	ConditionTypePreInstallHooks  = "PreInstallHooks"
+	ConditionTypePostInstallHooks = "PostInstallHooks"
+	ConditionTypePreUpgradeHooks  = "PreUpgradeHooks"
+	ConditionTypePreDeleteHooks   = "PreDeleteHooks"
+	ConditionTypePostDeleteHooks  = "PostDeleteHooks"
+
+	// ConditionTypeDeletionBlocked is True while the deletion of a Sample waits for resources created by users,
+	// such as ThirdParty resources, to be removed.
+	ConditionTypeDeletionBlocked = "DeletionBlocked"
+
+	conditionMessageReady = "installation is ready and resources can be used"
 )
 
 type SampleStatus struct {
@@ -46,6 +82,141 @@ type SampleStatus struct {
 	// Conditions contain a set of conditionals to determine the State of Status.
 	// If all Conditions are met, State is expected to be in StateReady.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Source describes the manifest source the resources were last loaded from.
+	// +optional
+	Source *SourceStatus `json:"source,omitempty"`
+
+	// SkippedDocuments lists documents of the manifest which could not be parsed and were skipped
+	// during installation in Lenient ParseMode. The list is limited to the first MaxSkippedDocuments entries.
+	// +optional
+	SkippedDocuments []SkippedDocument `json:"skippedDocuments,omitempty"`
+
+	// Inventory lists all resources applied with the last successful installation.
+	// Resources which are removed from the manifest are pruned based on the Inventory.
+	// Manifests with more than MaxInventoryEntries resources are not installed.
+	// +kubebuilder:validation:MaxItems=1000
+	// +optional
+	Inventory []InventoryEntry `json:"inventory,omitempty"`
+
+	// InstalledGeneration is the generation of the Sample whose resources were last applied successfully.
+	// Pre-upgrade hooks run once the generation of the Sample or the ManifestHash changes.
+	// +optional
+	InstalledGeneration int64 `json:"installedGeneration,omitempty"`
+
+	// Resources lists the apply result and health of the resources of the last installation.
+	// Resources which failed to apply or are not healthy are listed first,
+	// and the list is limited to the first MaxResourceStatuses entries.
+	// +optional
+	Resources []ResourceStatus `json:"resources,omitempty"`
+
+	// ResourcesReady summarizes the health of all resources of the last installation as healthy/total.
+	// +optional
+	ResourcesReady string `json:"resourcesReady,omitempty"`
+
+	// SyncWave is the sync wave of the manifest which is currently applied, or deleted while the Sample is deleted.
+	// Resources of later waves are applied once all resources of this wave are healthy.
+	// +optional
+	SyncWave *int32 `json:"syncWave,omitempty"`
+
+	// ManifestHash is the hash of the spec and the rendered manifest of the last installation.
+	// Resources are not applied again while it is unchanged, all resources are healthy and none of them changed.
+	// +optional
+	ManifestHash string `json:"manifestHash,omitempty"`
+
+	// PreUpgradeHooksHash is the manifest hash the pre-upgrade hooks last ran for.
+	// Pre-upgrade hooks run again for a manifest with another hash, even if the generation is unchanged.
+	// +optional
+	PreUpgradeHooksHash string `json:"preUpgradeHooksHash,omitempty"`
+}
+
+// MaxResourceStatuses is the maximum number of resources listed in the status of a Sample.
+const MaxResourceStatuses = 100
+
+// MaxInventoryEntries is the maximum number of resources a Sample installs, as all of them are listed
+// in its inventory and in the Managed resource. It must match the MaxItems validation of both lists.
+const MaxInventoryEntries = 1000
+
+// ApplyResult is the result of applying a resource with the last installation.
+// +kubebuilder:validation:Enum=Applied;Failed;Pending
+type ApplyResult string
+
+const (
+	ApplyResultApplied ApplyResult = "Applied"
+	ApplyResultFailed  ApplyResult = "Failed"
+	// ApplyResultPending is the result of resources of a sync wave which was not applied yet.
+	ApplyResultPending ApplyResult = "Pending"
+)
+
+// ResourceHealth is the health of an applied resource.
+// +kubebuilder:validation:Enum=Healthy;Progressing;Degraded;Unknown
+type ResourceHealth string
+
+const (
+	ResourceHealthHealthy     ResourceHealth = "Healthy"
+	ResourceHealthProgressing ResourceHealth = "Progressing"
+	ResourceHealthDegraded    ResourceHealth = "Degraded"
+	ResourceHealthUnknown     ResourceHealth = "Unknown"
+)
+
+// ResourceStatus is the apply result and health of a resource of the manifest.
+type ResourceStatus struct {
+	InventoryEntry `json:",inline"`
+
+	// ApplyResult of the resource with the last installation.
+	ApplyResult ApplyResult `json:"applyResult"`
+
+	// Health of the resource.
+	Health ResourceHealth `json:"health"`
+
+	// Message describes why a resource failed to apply or is not healthy.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// InventoryEntry identifies a resource applied by a Sample.
+type InventoryEntry struct {
+	// Group of the resource, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version of the resource.
+	Version string `json:"version"`
+
+	// Kind of the resource.
+	Kind string `json:"kind"`
+
+	// Namespace of the resource, empty for cluster scoped resources.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the resource.
+	Name string `json:"name"`
+}
+
+func (e InventoryEntry) String() string {
+	if e.Namespace == "" {
+		return e.Kind + " " + e.Name
+	}
+	return e.Kind + " " + e.Namespace + "/" + e.Name
+}
+
+// MaxSkippedDocuments is the maximum number of skipped documents listed in the status of a Sample.
+const MaxSkippedDocuments = 20
+
+// SkippedDocument is a document of the manifest which could not be parsed into a resource.
+type SkippedDocument struct {
+	// Source is the file or template the document originates from.
+	Source string `json:"source"`
+
+	// Index of the document in its source, starting at 0.
+	Index int `json:"index"`
+
+	// Line the document starts at in its source, starting at 1.
+	Line int `json:"line"`
+
+	// Message describes why the document could not be parsed.
+	Message string `json:"message"`
 }
 
 func (s *SampleStatus) WithState(state State) *SampleStatus {
@@ -54,35 +225,151 @@ func (s *SampleStatus) WithState(state State) *SampleStatus {
 }
 
 func (s *SampleStatus) WithInstallConditionStatus(status metav1.ConditionStatus, objGeneration int64) *SampleStatus {
-	if s.Conditions == nil {
-		s.Conditions = make([]metav1.Condition, 0, 1)
+	condition := s.getInstallCondition()
+	condition.Status = status
+	condition.ObservedGeneration = objGeneration
+	// a successful installation always resets the reason of previous failures
+	if status == metav1.ConditionTrue {
+		condition.Reason = ConditionReasonReady
+		condition.Message = conditionMessageReady
 	}
+	meta.SetStatusCondition(&s.Conditions, condition)
+	return s
+}
 
-	condition := meta.FindStatusCondition(s.Conditions, ConditionTypeInstallation)
+// WithInstallConditionReason sets the reason and message of the Installation condition,
+// e.g. to surface why an installation failed.
+func (s *SampleStatus) WithInstallConditionReason(reason, message string) *SampleStatus {
+	condition := s.getInstallCondition()
+	condition.Reason = reason
+	condition.Message = message
+	meta.SetStatusCondition(&s.Conditions, condition)
+	return s
+}
 
-	if condition == nil {
-		condition = &metav1.Condition{
-			Type:    ConditionTypeInstallation,
-			Reason:  ConditionReasonReady,
-			Message: "installation is ready and resources can be used",
-		}
+// getInstallCondition returns a copy of the Installation condition, or a new one if it is not present yet.
+func (s *SampleStatus) getInstallCondition() metav1.Condition {
+	if s.Conditions == nil {
+		s.Conditions = make([]metav1.Condition, 0, 1)
 	}
 
-	condition.Status = status
-	condition.ObservedGeneration = objGeneration
-	meta.SetStatusCondition(&s.Conditions, *condition)
-	return s
+	if condition := meta.FindStatusCondition(s.Conditions, ConditionTypeInstallation); condition != nil {
+		return *condition
+	}
+	return metav1.Condition{
+		Type:    ConditionTypeInstallation,
+		Status:  metav1.ConditionUnknown,
+		Reason:  ConditionReasonReady,
+		Message: conditionMessageReady,
+	}
 }
 
 type SampleSpec struct {
-	// ResourceFilePath indicates the local dir path containing a .yaml or .yml,
-	// with all required resources to be processed
+	// ResourceFilePath indicates the local dir path containing .yaml, .yml or .json files,
+	// or the path of a single such file, with all required resources to be processed.
+	// If the dir path contains a kustomization.yaml, it is built as a kustomization.
+	// If the dir path contains a Chart.yaml, it is rendered as a Helm chart with the Sample as release.
 	ResourceFilePath string `json:"resourceFilePath,omitempty"`
+
+	// Recursive includes the manifest files of all subdirectories of ResourceFilePath.
+	// +optional
+	Recursive bool `json:"recursive,omitempty"`
+
+	// Include contains glob patterns selecting the manifest files of ResourceFilePath to be processed.
+	// Patterns are matched against the path relative to ResourceFilePath,
+	// patterns without a slash are matched against the file name only. Defaults to all files.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude contains glob patterns of manifest files of ResourceFilePath not to be processed.
+	// Exclude takes precedence over Include.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+
+	// IndexFile is the path of a file relative to ResourceFilePath listing the manifest files
+	// to be processed in order, one path per line. Without an IndexFile, manifest files
+	// are processed in lexical order of their paths.
+	// +optional
+	IndexFile string `json:"indexFile,omitempty"`
+
+	// ParseMode defines how documents of the manifest which cannot be parsed into a resource are handled.
+	// Strict fails the installation, Lenient installs all other resources and sets the Sample to Warning.
+	// +kubebuilder:default=Strict
+	// +optional
+	ParseMode ParseMode `json:"parseMode,omitempty"`
+
+	// ReleaseName is the name of the release a Helm chart is rendered with.
+	// Defaults to the name of the Sample.
+	ReleaseName string `json:"releaseName,omitempty"`
+
+	// Values are inline values a Helm chart is rendered with.
+	// They take precedence over the values of the chart and all values referenced in ValuesFrom.
+	// +optional
+	Values *runtime.RawExtension `json:"values,omitempty"`
+
+	// ValuesFrom references ConfigMaps and Secrets in the namespace of the Sample containing values
+	// a Helm chart is rendered with. Values of later references take precedence over earlier ones.
+	// +optional
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+
+	// Prune deletes resources which were applied by a previous installation but are removed from the manifest.
+	// Single resources can be excluded from pruning with the operator.kyma-project.io/prune: "false" annotation.
+	// +kubebuilder:default=true
+	// +optional
+	Prune *bool `json:"prune,omitempty"`
+
+	// HealthRules define the health of applied resources as CEL expressions. They take precedence over
+	// the health rules of the operator and the built-in health evaluation for the same group and kind.
+	// +optional
+	HealthRules []HealthRule `json:"healthRules,omitempty"`
+
+	// DeletionPropagation is the propagation policy resources are deleted with, when the Sample is deleted
+	// or resources are pruned. Foreground deletes dependents of a resource, such as the Pods of a Deployment,
+	// before the resource itself, Orphan keeps them.
+	// +kubebuilder:validation:Enum=Foreground;Background;Orphan
+	// +kubebuilder:default=Background
+	// +optional
+	DeletionPropagation metav1.DeletionPropagation `json:"deletionPropagation,omitempty"`
+
+	// Source configures a manifest source other than the local ResourceFilePath.
+	// Only one of ResourceFilePath and Source can be set.
+	// +optional
+	Source *ManifestSource `json:"source,omitempty"`
+}
+
+// ParseMode defines how documents of a manifest which cannot be parsed are handled.
+// +kubebuilder:validation:Enum=Strict;Lenient
+type ParseMode string
+
+const (
+	ParseModeStrict  ParseMode = "Strict"
+	ParseModeLenient ParseMode = "Lenient"
+)
+
+const DefaultValuesKey = "values.yaml"
+
+// ValuesReference points to a key of a ConfigMap or Secret containing values in YAML format.
+type ValuesReference struct {
+	// Kind of the referenced resource, either ConfigMap or Secret.
+	Kind ReferenceKind `json:"kind"`
+
+	// Name of the referenced resource in the namespace of the Sample.
+	Name string `json:"name"`
+
+	// Key in the data of the referenced resource containing the values.
+	// +kubebuilder:default=values.yaml
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Optional marks the reference as optional, in which case a missing resource or key is ignored.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="State",type=string,JSONPath=".status.state"
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.resourcesReady"
 
 // Sample is the Schema for the samples API.
 type Sample struct {
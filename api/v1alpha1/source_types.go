@@ -0,0 +1,134 @@
+package v1alpha1
+
+// ReferenceKind is the kind of in-cluster resource data is referenced from.
+// +kubebuilder:validation:Enum=ConfigMap;Secret
+type ReferenceKind string
+
+const (
+	ReferenceKindConfigMap ReferenceKind = "ConfigMap"
+	ReferenceKindSecret    ReferenceKind = "Secret"
+)
+
+// ManifestSource configures where the manifest of a Sample is loaded from.
+// Only one source can be set.
+type ManifestSource struct {
+	// KeyRefs reference keys of ConfigMaps and Secrets in the namespace of the Sample containing the manifest,
+	// either in data or binaryData, optionally gzip compressed.
+	// The manifests of all keys are merged in the order of declaration.
+	// +optional
+	KeyRefs []ManifestKeyReference `json:"keyRefs,omitempty"`
+
+	// URL configures a manifest fetched over HTTP(S).
+	// +optional
+	URL *URLSource `json:"url,omitempty"`
+
+	// OCI configures a manifest read from the layers of an image in an OCI image layout.
+	// +optional
+	OCI *OCISource `json:"oci,omitempty"`
+
+	// Git configures a manifest loaded from a path of a git repository.
+	// +optional
+	Git *GitSource `json:"git,omitempty"`
+}
+
+// ManifestKeyReference points to a key of a ConfigMap or Secret containing a manifest.
+type ManifestKeyReference struct {
+	// Kind of the referenced resource, either ConfigMap or Secret.
+	Kind ReferenceKind `json:"kind"`
+
+	// Name of the referenced resource in the namespace of the Sample.
+	Name string `json:"name"`
+
+	// Key in the data or binaryData of the referenced resource containing the manifest.
+	Key string `json:"key"`
+}
+
+// URLSource configures a manifest fetched over HTTP(S), pinned to the SHA256 digest of its content.
+type URLSource struct {
+	// URL of the manifest, optionally gzip compressed.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// SHA256 is the expected hex encoded SHA256 digest of the content served at URL.
+	// Content with a different digest is never installed.
+	// +kubebuilder:validation:Pattern=`^[a-f0-9]{64}$`
+	SHA256 string `json:"sha256"`
+
+	// AuthSecretName is the name of a Secret in the namespace of the Sample containing the credentials
+	// to fetch the manifest with, either the keys username and password for basic auth, or token for bearer auth.
+	// +optional
+	AuthSecretName string `json:"authSecretName,omitempty"`
+}
+
+// OCISource configures a manifest read from an image of an OCI image layout, selected by tag or digest.
+// The manifest is extracted from all layers of the image with a YAML media type,
+// or with a tar media type containing .yaml, .yml or .json files, in the order of the layers.
+// +kubebuilder:validation:XValidation:rule="has(self.tag) != has(self.digest)",message="exactly one of tag and digest must be set"
+type OCISource struct {
+	// LayoutPath is the local path of the OCI image layout directory.
+	LayoutPath string `json:"layoutPath"`
+
+	// Tag selects the image by its org.opencontainers.image.ref.name annotation in the index of the layout.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// Digest selects the image by the digest of its image manifest.
+	// +kubebuilder:validation:Pattern=`^sha256:[a-f0-9]{64}$`
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
+// GitSource configures a manifest loaded from a path of a git repository at a branch, tag or commit.
+// +kubebuilder:validation:XValidation:rule="[has(self.branch), has(self.tag), has(self.commit)].filter(x, x).size() == 1",message="exactly one of branch, tag and commit must be set"
+type GitSource struct {
+	// URL of the repository, e.g. an https:// or a file:// remote.
+	URL string `json:"url"`
+
+	// Branch to track. New commits on the branch are installed with the next resync of the operator.
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Tag to check out.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// Commit SHA to check out.
+	// +kubebuilder:validation:Pattern=`^[a-f0-9]{40}$`
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// Path in the repository the manifest is loaded from, the same way as from ResourceFilePath.
+	// Defaults to the root of the repository.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// SourceStatus describes the manifest source resources were loaded from.
+type SourceStatus struct {
+	// Type of the manifest source.
+	Type string `json:"type"`
+
+	// ReferencedObjects lists the ConfigMaps and Secrets the manifest was loaded from.
+	// +optional
+	ReferencedObjects []ReferencedObject `json:"referencedObjects,omitempty"`
+
+	// Digest of the manifest content or image the resources were loaded from, if the source is content addressed.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// Commit SHA of the git repository the resources were loaded from.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+}
+
+// ReferencedObject is an in-cluster resource a manifest was loaded from.
+type ReferencedObject struct {
+	// Kind of the referenced resource.
+	Kind ReferenceKind `json:"kind"`
+
+	// Name of the referenced resource in the namespace of the Sample.
+	Name string `json:"name"`
+
+	// ResourceVersion of the referenced resource the manifest was loaded from.
+	ResourceVersion string `json:"resourceVersion"`
+}
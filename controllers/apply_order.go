@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// applyPhase groups kinds which are applied together, phases are applied in ascending order.
+type applyPhase int
+
+const (
+	applyPhaseNamespaces applyPhase = iota
+	applyPhaseDefinitions
+	applyPhaseAccess
+	applyPhaseConfiguration
+	applyPhaseWorkloads
+	applyPhaseBuiltIn
+	applyPhaseCustomResources
+)
+
+//nolint:gochecknoglobals
+var (
+	crdGroupKind = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+
+	kindApplyPhases = map[schema.GroupKind]applyPhase{
+		{Kind: "Namespace"}: applyPhaseNamespaces,
+
+		crdGroupKind: applyPhaseDefinitions,
+
+		{Kind: "ServiceAccount"}:                                         applyPhaseAccess,
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:        applyPhaseAccess,
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}: applyPhaseAccess,
+		{Group: "rbac.authorization.k8s.io", Kind: "Role"}:               applyPhaseAccess,
+		{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:        applyPhaseAccess,
+
+		{Kind: "ConfigMap"}: applyPhaseConfiguration,
+		{Kind: "Secret"}:    applyPhaseConfiguration,
+
+		{Kind: "PersistentVolume"}:           applyPhaseWorkloads,
+		{Kind: "PersistentVolumeClaim"}:      applyPhaseWorkloads,
+		{Kind: "Service"}:                    applyPhaseWorkloads,
+		{Kind: "Pod"}:                        applyPhaseWorkloads,
+		{Group: "apps", Kind: "DaemonSet"}:   applyPhaseWorkloads,
+		{Group: "apps", Kind: "Deployment"}:  applyPhaseWorkloads,
+		{Group: "apps", Kind: "ReplicaSet"}:  applyPhaseWorkloads,
+		{Group: "apps", Kind: "StatefulSet"}: applyPhaseWorkloads,
+		{Group: "batch", Kind: "Job"}:        applyPhaseWorkloads,
+		{Group: "batch", Kind: "CronJob"}:    applyPhaseWorkloads,
+	}
+)
+
+// applyPhaseOf returns the phase obj is applied in. Kinds of built-in groups which are not listed explicitly
+// are applied after workloads, custom resources are applied last, so their definitions exist.
+func applyPhaseOf(obj *unstructured.Unstructured) applyPhase {
+	groupKind := obj.GroupVersionKind().GroupKind()
+	if phase, found := kindApplyPhases[groupKind]; found {
+		return phase
+	}
+	if !strings.Contains(groupKind.Group, ".") || strings.HasSuffix(groupKind.Group, ".k8s.io") {
+		return applyPhaseBuiltIn
+	}
+	return applyPhaseCustomResources
+}
+
+// applyOrder is the position of a resource in the order resources are applied in.
+type applyOrder struct {
+	wave  int32
+	phase applyPhase
+}
+
+func applyOrderOf(obj *unstructured.Unstructured) applyOrder {
+	return applyOrder{wave: syncWaveOf(obj), phase: applyPhaseOf(obj)}
+}
+
+func (o applyOrder) before(other applyOrder) bool {
+	if o.wave != other.wave {
+		return o.wave < other.wave
+	}
+	return o.phase < other.phase
+}
+
+// sortByApplyOrder sorts objs by their sync wave and the phase they are applied in within their wave,
+// keeping the manifest order within a phase.
+func sortByApplyOrder(objs []*unstructured.Unstructured) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		return applyOrderOf(objs[i]).before(applyOrderOf(objs[j]))
+	})
+}
+
+func isCustomResourceDefinition(obj *unstructured.Unstructured) bool {
+	return obj.GroupVersionKind().GroupKind() == crdGroupKind
+}
+
+// isEstablished reports whether the applied CustomResourceDefinition crd is established and its kind can be
+// mapped by the REST mapper of the client, so instances of it can be applied. It does not wait for it, the
+// resources depending on crd are applied with a later reconciliation, see pendingDefinitionStatus.
+func (r *SampleReconciler) isEstablished(ctx context.Context, crd *unstructured.Unstructured) (bool, string) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(crd.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKeyFromObject(crd), current); err != nil {
+		return false, fmt.Sprintf("definition could not be read: %v", err)
+	}
+	conditions, _, _ := unstructured.NestedSlice(current.Object, "status", "conditions")
+	if condition := findCondition(conditions, "Established"); condition == nil || condition.status != "True" {
+		return false, "waiting for the definition to be established"
+	}
+
+	groupKind := schema.GroupKind{}
+	groupKind.Group, _, _ = unstructured.NestedString(crd.Object, "spec", "group")
+	groupKind.Kind, _, _ = unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	_, err := r.RESTMapper().RESTMapping(groupKind)
+	// discovery is cached by the REST mapper, reset it on misses if possible, the dynamic mapper reloads itself
+	if resettable, ok := r.RESTMapper().(meta.ResettableRESTMapper); ok && err != nil {
+		resettable.Reset()
+		_, err = r.RESTMapper().RESTMapping(groupKind)
+	}
+	if err != nil {
+		return false, fmt.Sprintf("waiting for the kind %s to be served: %v", groupKind, err)
+	}
+	return true, ""
+}
+
+// pendingDefinitionStatus is the status of a resource which is applied once the CustomResourceDefinitions
+// of its sync wave are established.
+func pendingDefinitionStatus(obj *unstructured.Unstructured) v1alpha1.ResourceStatus {
+	return v1alpha1.ResourceStatus{
+		InventoryEntry: inventoryEntryOf(obj),
+		ApplyResult:    v1alpha1.ApplyResultPending,
+		Health:         v1alpha1.ResourceHealthUnknown,
+		Message:        "waiting for the CustomResourceDefinitions of the sync wave to be established",
+	}
+}
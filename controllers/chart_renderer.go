@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+const (
+	chartFileName  = "Chart.yaml"
+	notesFileName  = "NOTES.txt"
+	releaseVersion = 1
+)
+
+// renderOptions contains the release information a Helm chart is rendered with.
+type renderOptions struct {
+	releaseName string
+	namespace   string
+	values      map[string]interface{}
+}
+
+// isHelmChart checks if the passed dirPath is the root directory of a Helm chart.
+func isHelmChart(dirPath string) bool {
+	info, err := os.Stat(filepath.Join(dirPath, chartFileName))
+	return err == nil && !info.IsDir()
+}
+
+// renderHelmChart renders the Helm chart located at chartPath in-process and returns the resources
+// in unstructured format. CRDs of the chart are placed before all rendered templates.
+func renderHelmChart(chartPath string, opts renderOptions) (*ManifestResources, error) {
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, &renderError{err: fmt.Errorf("helm chart could not be loaded from %s: %w", chartPath, err)}
+	}
+
+	releaseOptions := chartutil.ReleaseOptions{
+		Name:      opts.releaseName,
+		Namespace: opts.namespace,
+		Revision:  releaseVersion,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(chart, opts.values, releaseOptions, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, &renderError{err: fmt.Errorf("helm values could not be prepared for chart %s: %w", chartPath, err)}
+	}
+
+	renderedTemplates, err := engine.Render(chart, renderValues)
+	if err != nil {
+		return nil, &renderError{err: fmt.Errorf("helm chart %s could not be rendered: %w", chartPath, err)}
+	}
+
+	resources := &ManifestResources{}
+	for _, crd := range chart.CRDObjects() {
+		resources.merge(parseManifestStringToObjects(crd.Filename, string(crd.File.Data)))
+	}
+
+	// rendered templates are returned as a map, sort them to keep the order of resources stable
+	templateNames := make([]string, 0, len(renderedTemplates))
+	for name := range renderedTemplates {
+		templateNames = append(templateNames, name)
+	}
+	sort.Strings(templateNames)
+
+	for _, name := range templateNames {
+		// partials and notes are not part of the manifest
+		if filepath.Base(name) == notesFileName || strings.HasPrefix(filepath.Base(name), "_") {
+			continue
+		}
+		resources.merge(parseManifestStringToObjects(name, renderedTemplates[name]))
+	}
+	return resources, nil
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// getHelmValues returns the values a Helm chart is rendered with for the reconciled resource.
+// Values are merged in the following order of precedence, from lowest to highest:
+// ValuesFrom references in the order of declaration, followed by the inline Values.
+// The values.yaml of the chart itself is coalesced by Helm and has the lowest precedence.
+func (r *SampleReconciler) getHelmValues(ctx context.Context,
+	objectInstance *v1alpha1.Sample,
+) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, ref := range objectInstance.Spec.ValuesFrom {
+		refValues, err := r.getReferencedValues(ctx, objectInstance.GetNamespace(), ref)
+		if err != nil {
+			return nil, err
+		}
+		values = mergeValues(values, refValues)
+	}
+
+	if objectInstance.Spec.Values != nil && len(objectInstance.Spec.Values.Raw) > 0 {
+		inlineValues := map[string]interface{}{}
+		if err := yaml.Unmarshal(objectInstance.Spec.Values.Raw, &inlineValues); err != nil {
+			return nil, fmt.Errorf("inline values could not be parsed: %w", err)
+		}
+		values = mergeValues(values, inlineValues)
+	}
+	return values, nil
+}
+
+// getReferencedValues reads the values from the ConfigMap or Secret referenced by ref.
+func (r *SampleReconciler) getReferencedValues(ctx context.Context, namespace string,
+	ref v1alpha1.ValuesReference,
+) (map[string]interface{}, error) {
+	key := ref.Key
+	if key == "" {
+		key = v1alpha1.DefaultValuesKey
+	}
+
+	data, _, found, err := r.getReferencedData(ctx, namespace, ref.Kind, ref.Name, key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if ref.Optional {
+			return map[string]interface{}{}, nil
+		}
+		return nil, fmt.Errorf("values key %s of %s %s/%s not found", key, ref.Kind, namespace, ref.Name)
+	}
+
+	values := map[string]interface{}{}
+	if err = yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("values key %s of %s %s/%s could not be parsed: %w", key, ref.Kind, namespace, ref.Name, err)
+	}
+	return values, nil
+}
+
+// mergeValues deep merges override into base, values of override take precedence.
+// Nested maps are merged, all other values are replaced.
+func mergeValues(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		overrideMap, isMap := value.(map[string]interface{})
+		if baseMap, baseIsMap := merged[key].(map[string]interface{}); isMap && baseIsMap {
+			merged[key] = mergeValues(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
@@ -1,17 +1,12 @@
 package controllers
 
 import (
-	"bufio"
 	"bytes"
-	"errors"
-	"fmt"
-	"io"
 	"strings"
 	"time"
 
 	"golang.org/x/time/rate"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	yamlUtil "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/yaml"
@@ -29,34 +24,94 @@ const (
 	finalizer       = "sample.kyma-project.io/finalizer"
 	debugLogLevel   = 2
 	fieldOwner      = "sample.kyma-project.io/owner"
+	yamlSeparator   = "---"
 )
 
+// manifestDocument is a single document of a multi-document YAML manifest.
+type manifestDocument struct {
+	// index of the document in the manifest, starting at 0
+	index int
+	// line the document starts at in the manifest, starting at 1
+	line int
+	data []byte
+}
+
 // parseManifestStringToObjects parses the string of resources into a list of unstructured resources.
-func parseManifestStringToObjects(manifest string) (*ManifestResources, error) {
+// Documents which cannot be parsed into a resource are collected as blobs, together with their
+// position in the manifest and the parsing error. The source describes where the manifest originates from.
+func parseManifestStringToObjects(source, manifest string) *ManifestResources {
 	objects := &ManifestResources{}
-	reader := yamlUtil.NewYAMLReader(bufio.NewReader(strings.NewReader(manifest)))
-	for {
-		rawBytes, err := reader.Read()
+	for _, document := range splitManifestDocuments(manifest) {
+		jsonBytes, err := yaml.YAMLToJSON(document.data)
+		if err == nil && bytes.Equal(jsonBytes, []byte("null")) {
+			// documents only containing comments
+			continue
+		}
+
+		unstructuredObj := unstructured.Unstructured{}
+		if err == nil {
+			err = unstructuredObj.UnmarshalJSON(jsonBytes)
+		}
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				return objects, nil
-			}
+			objects.Blobs = append(objects.Blobs, ManifestBlob{
+				Source: source,
+				Index:  document.index,
+				Line:   document.line,
+				Data:   document.data,
+				Err:    err,
+			})
+			continue
+		}
 
-			return nil, fmt.Errorf("invalid YAML doc: %w", err)
+		if len(unstructuredObj.Object) == 0 {
+			continue
 		}
+		objects.Items = append(objects.Items, &unstructuredObj)
+	}
+	return objects
+}
 
-		rawBytes = bytes.TrimSpace(rawBytes)
-		unstructuredObj := unstructured.Unstructured{}
-		if err := yaml.Unmarshal(rawBytes, &unstructuredObj); err != nil {
-			objects.Blobs = append(objects.Blobs, append(bytes.TrimPrefix(rawBytes, []byte("---\n")), '\n'))
+// splitManifestDocuments splits a multi-document YAML manifest at its document separators,
+// in the same way as the YAML reader of the apimachinery, but keeps track of the line each document starts at.
+// Documents only containing whitespace are omitted.
+func splitManifestDocuments(manifest string) []manifestDocument {
+	documents := make([]manifestDocument, 0)
+	buffer := bytes.Buffer{}
+	startLine := 1
+	flush := func() {
+		if len(bytes.TrimSpace(buffer.Bytes())) > 0 {
+			documents = append(documents, manifestDocument{
+				index: len(documents),
+				line:  startLine,
+				data:  append([]byte(nil), buffer.Bytes()...),
+			})
 		}
+		buffer.Reset()
+	}
 
-		if len(rawBytes) == 0 || bytes.Equal(rawBytes, []byte("null")) || len(unstructuredObj.Object) == 0 {
+	for i, line := range strings.SplitAfter(manifest, "\n") {
+		lineNumber := i + 1
+		if isDocumentSeparator(line) {
+			flush()
+			startLine = lineNumber + 1
 			continue
 		}
+		if buffer.Len() == 0 && strings.TrimSpace(line) == "" {
+			startLine = lineNumber + 1
+			continue
+		}
+		buffer.WriteString(line)
+	}
+	flush()
+	return documents
+}
 
-		objects.Items = append(objects.Items, &unstructuredObj)
+func isDocumentSeparator(line string) bool {
+	if !strings.HasPrefix(line, yamlSeparator) {
+		return false
 	}
+	trimmed := strings.TrimSpace(strings.TrimPrefix(line, yamlSeparator))
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
 }
 
 // TemplateRateLimiter implements a rate limiter for a client-go.workqueue.  It has
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const cacheFilePermission = 0o600
+
+// contentCache stores fetched manifest content on disk, keyed by its SHA256 digest.
+// A contentCache without a directory caches nothing.
+type contentCache struct {
+	dir string
+}
+
+// get returns the cached content with the passed hex encoded SHA256 digest.
+// Content which does not match its digest anymore is removed from the cache and reported as not cached.
+func (c contentCache) get(digest string) ([]byte, bool, error) {
+	if c.dir == "" {
+		return nil, false, nil
+	}
+
+	content, err := os.ReadFile(c.path(digest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("cached manifest %s could not be read: %w", digest, err)
+	}
+	if sha256Digest(content) != digest {
+		return nil, false, os.Remove(c.path(digest))
+	}
+	return content, true, nil
+}
+
+// put stores the content under its hex encoded SHA256 digest.
+// The content is written to a temporary file first, so concurrent readers never see partial content.
+func (c contentCache) put(digest string, content []byte) error {
+	if c.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return fmt.Errorf("cache directory %s could not be created: %w", c.dir, err)
+	}
+	tmpFile, err := os.CreateTemp(c.dir, digest+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cached manifest %s could not be created: %w", digest, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("cached manifest %s could not be written: %w", digest, err)
+	}
+	if err = tmpFile.Chmod(cacheFilePermission); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("cached manifest %s could not be written: %w", digest, err)
+	}
+	if err = tmpFile.Close(); err != nil {
+		return fmt.Errorf("cached manifest %s could not be written: %w", digest, err)
+	}
+	return os.Rename(tmpFile.Name(), c.path(digest))
+}
+
+func (c contentCache) path(digest string) string {
+	return filepath.Join(c.dir, "sha256-"+digest)
+}
+
+// sha256Digest returns the hex encoded SHA256 digest of content.
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
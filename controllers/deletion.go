@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// deleteResources deletes objs in the reverse order they are applied in, sync wave by sync wave and
+// phase by phase, see sortByApplyOrder. The resources of a phase are only deleted once all resources
+// of the later waves and phases are gone, so custom resources are gone before their definitions
+// and workloads before their namespaces.
+// It returns the resources which are not gone yet, so deletion needs to be continued later.
+func (r *SampleReconciler) deleteResources(ctx context.Context, objectInstance *v1alpha1.Sample,
+	objs []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	sorted := make([]*unstructured.Unstructured, len(objs))
+	copy(sorted, objs)
+	sortByApplyOrder(sorted)
+
+	propagationPolicy := client.PropagationPolicy(deletionPropagation(objectInstance))
+	remaining := make([]*unstructured.Unstructured, 0)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		obj := sorted[i]
+		// all resources of the previous phase need to be gone before the next phase is deleted
+		if len(remaining) > 0 && applyOrderOf(obj) != applyOrderOf(remaining[len(remaining)-1]) {
+			break
+		}
+
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		err := r.Get(ctx, client.ObjectKeyFromObject(obj), current)
+		if errors2.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s could not be read for deletion: %w", inventoryEntryOf(obj), err)
+		}
+
+		remaining = append(remaining, obj)
+		if current.GetDeletionTimestamp().IsZero() {
+			if err = r.Delete(ctx, current, propagationPolicy); client.IgnoreNotFound(err) != nil {
+				return nil, fmt.Errorf("%s could not be deleted: %w", inventoryEntryOf(obj), err)
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// inventoryResources returns the existing resources of inventory, so they can be deleted in the order of
+// their sync wave annotations and kinds when the manifest they were applied from cannot be resolved anymore.
+func (r *SampleReconciler) inventoryResources(ctx context.Context, inventory []v1alpha1.InventoryEntry,
+) ([]*unstructured.Unstructured, error) {
+	objs := make([]*unstructured.Unstructured, 0, len(inventory))
+	for _, entry := range inventory {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: entry.Group, Version: entry.Version, Kind: entry.Kind})
+		err := r.Get(ctx, client.ObjectKey{Namespace: entry.Namespace, Name: entry.Name}, obj)
+		if errors2.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s could not be read for deletion: %w", entry, err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// blockingResources lists the resources of the DeletionBlockingKinds in all namespaces, which are not resources
+// of the manifest objs and so were created by users. Kinds which are not installed in the cluster are skipped.
+func (r *SampleReconciler) blockingResources(ctx context.Context, objs []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	owned := sets.New[inventoryKey]()
+	for _, obj := range objs {
+		owned.Insert(keyOf(inventoryEntryOf(obj)))
+	}
+
+	blocking := make([]*unstructured.Unstructured, 0)
+	for _, gvk := range r.DeletionBlockingKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := r.List(ctx, list)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("resources of kind %s blocking the deletion could not be listed: %w", gvk.Kind, err)
+		}
+		for i := range list.Items {
+			if !owned.Has(keyOf(inventoryEntryOf(&list.Items[i]))) {
+				blocking = append(blocking, &list.Items[i])
+			}
+		}
+	}
+	return blocking, nil
+}
+
+// checkDeletionBlocked updates the DeletionBlocked condition of status for the blocking resources, which are
+// not resources of objs. It reports whether the deletion is blocked, in which case status is already updated.
+func (r *SampleReconciler) checkDeletionBlocked(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus, objs []*unstructured.Unstructured,
+) (bool, error) {
+	blocking, err := r.blockingResources(ctx, objs)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error listing resources blocking the deletion")
+		return false, err
+	}
+	r.withDeletionBlockedStatus(status, objectInstance.GetGeneration(), blocking)
+	if len(blocking) == 0 {
+		return false, nil
+	}
+	if !isDeletionBlocked(&objectInstance.Status) {
+		r.Event(objectInstance, "Warning", v1alpha1.ConditionTypeDeletionBlocked,
+			"deletion waits for resources to be removed: "+describeObjects(blocking))
+	}
+	return true, r.setChangedStatus(ctx, objectInstance, status)
+}
+
+// withDeletionBlockedStatus sets the reconciled resource to Warning with the DeletionBlocked condition
+// while blocking resources exist, and back to FinalDeletionState once they are removed.
+func (r *SampleReconciler) withDeletionBlockedStatus(status *v1alpha1.SampleStatus, objGeneration int64,
+	blocking []*unstructured.Unstructured,
+) *v1alpha1.SampleStatus {
+	if len(blocking) == 0 {
+		if meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ConditionTypeDeletionBlocked) {
+			status.WithState(r.FinalDeletionState)
+		}
+		return status
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionTypeDeletionBlocked,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: objGeneration,
+		Reason:             v1alpha1.ConditionReasonBlockingResourcesExist,
+		Message:            "deletion waits for resources to be removed: " + describeObjects(blocking),
+	})
+	return status.WithState(v1alpha1.StateWarning)
+}
+
+// isDeletionBlocked reports whether the deletion of the reconciled resource waits for blocking resources.
+func isDeletionBlocked(status *v1alpha1.SampleStatus) bool {
+	return meta.IsStatusConditionTrue(status.Conditions, v1alpha1.ConditionTypeDeletionBlocked)
+}
+
+// deletionPropagation returns the propagation policy resources of the reconciled resource are deleted with.
+func deletionPropagation(objectInstance *v1alpha1.Sample) metav1.DeletionPropagation {
+	if objectInstance.Spec.DeletionPropagation == "" {
+		return metav1.DeletePropagationBackground
+	}
+	return objectInstance.Spec.DeletionPropagation
+}
+
+// describeObjects lists the first resources of objs, limited like the resources listed while waiting for readiness.
+func describeObjects(objs []*unstructured.Unstructured) string {
+	names := make([]string, 0, maxListedNotReadyResources+1)
+	for _, obj := range objs[:min(len(objs), maxListedNotReadyResources)] {
+		names = append(names, inventoryEntryOf(obj).String())
+	}
+	if len(objs) > len(names) {
+		names = append(names, fmt.Sprintf("and %d more", len(objs)-len(names)))
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseGroupVersionKinds parses a comma separated list of kinds in the format Kind.version.group,
+// such as ThirdParty.v1alpha1.operator.kyma-project.io or ConfigMap.v1. for the core group.
+func ParseGroupVersionKinds(value string) ([]schema.GroupVersionKind, error) {
+	gvks := make([]schema.GroupVersionKind, 0)
+	for _, kind := range strings.Split(value, ",") {
+		if kind = strings.TrimSpace(kind); kind == "" {
+			continue
+		}
+		gvk, _ := schema.ParseKindArg(kind)
+		if gvk == nil {
+			return nil, fmt.Errorf("kind %q is not in the format Kind.version.group", kind)
+		}
+		gvks = append(gvks, *gvk)
+	}
+	return gvks, nil
+}
@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+const gitCheckoutsDir = "git"
+
+// serveLocalRepositories serves file:// remotes in-process from below root, so they can be used without a git
+// binary in the operator image. Remotes outside of root are not found, without a root all file:// remotes.
+// The protocol is installed for the whole process, as the transport of a fetch cannot be passed to go-git.
+func serveLocalRepositories(root string) {
+	gitclient.InstallProtocol("file", server.NewServer(localRepositoryLoader{root: root}))
+}
+
+// localRepositoryLoader loads bare repositories as well as repositories with a working tree from below root.
+type localRepositoryLoader struct {
+	root string
+}
+
+func (l localRepositoryLoader) Load(endpoint *transport.Endpoint) (storer.Storer, error) {
+	if !isWithinRoot(endpoint.Path, l.root) {
+		return nil, transport.ErrRepositoryNotFound
+	}
+	for _, path := range []string{endpoint.Path, filepath.Join(endpoint.Path, git.GitDirName)} {
+		repoEndpoint := *endpoint
+		repoEndpoint.Path = path
+		if repoStorer, err := server.DefaultLoader.Load(&repoEndpoint); err == nil {
+			return repoStorer, nil
+		}
+	}
+	return nil, transport.ErrRepositoryNotFound
+}
+
+// getResourcesFromGit returns the resources at the path of the git repository configured in spec.source.git,
+// loaded the same way as from the local ResourceFilePath. Namespaced resources without a namespace are installed
+// into the namespace of the reconciled resource. With a ManifestCacheDir, the checkout of the repository is kept
+// between reconciliations and fetched again only after spec.source.git changed or once the ResyncInterval elapsed,
+// so new commits on a tracked branch are installed with the next resync. Without a ManifestCacheDir,
+// the repository is fetched into a temporary checkout with every reconciliation.
+func (r *SampleReconciler) getResourcesFromGit(ctx context.Context,
+	objectInstance *v1alpha1.Sample,
+) (*ManifestResources, error) {
+	gitSource := objectInstance.Spec.Source.Git
+	if err := r.checkGitRemote(gitSource.URL); err != nil {
+		return nil, err
+	}
+	checkoutDir, cleanup, err := r.gitCheckoutDir(objectInstance)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	repo, err := openGitRepository(checkoutDir, gitSource.URL)
+	if err != nil {
+		return nil, err
+	}
+	key := client.ObjectKeyFromObject(objectInstance)
+	fetch := r.gitFetches.due(key, *gitSource, r.ResyncInterval)
+	commit, err := fetchGitRevision(ctx, repo, gitSource, fetch)
+	if err != nil {
+		return nil, err
+	}
+	if fetch {
+		r.gitFetches.set(key, *gitSource)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("worktree of git repository %s could not be opened: %w", gitSource.URL, err)
+	}
+	if err = worktree.Checkout(&git.CheckoutOptions{Hash: commit, Force: true}); err != nil {
+		return nil, fmt.Errorf("commit %s of git repository %s could not be checked out: %w",
+			commit, gitSource.URL, err)
+	}
+
+	resourcePath, err := gitResourcePath(checkoutDir, gitSource)
+	if err != nil {
+		return nil, err
+	}
+	// temporary checkouts are removed after use, so their files are not cached
+	files := r.manifestFiles
+	if r.ManifestCacheDir == "" {
+		files = nil
+	}
+	resources, err := r.loadLocalManifest(ctx, objectInstance, resourcePath, files)
+	if err != nil {
+		return nil, err
+	}
+	resources.Source = v1alpha1.SourceStatus{Type: string(sourceTypeGit), Commit: commit.String()}
+	return resources, r.setDefaultNamespace(resources, objectInstance.GetNamespace())
+}
+
+// checkGitRemote rejects file:// remotes, including plain local paths, which are not below GitFileRoot.
+func (r *SampleReconciler) checkGitRemote(url string) error {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return fmt.Errorf("%w: git repository URL %s is invalid, check spec.source.git.url: %w",
+			errManifestNotFound, url, err)
+	}
+	if endpoint.Protocol == "file" && !isWithinRoot(endpoint.Path, r.GitFileRoot) {
+		return fmt.Errorf("%w: git repository %s does not exist below the git file root of the operator, "+
+			"check spec.source.git.url", errManifestNotFound, url)
+	}
+	return nil
+}
+
+// isWithinRoot reports whether path exists and is root or below it, after resolving symbolic links.
+// Nothing is within an empty root.
+func isWithinRoot(path, root string) bool {
+	if root == "" {
+		return false
+	}
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	resolvedPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	return isPathWithin(resolvedPath, resolvedRoot)
+}
+
+// gitCheckoutDir returns the directory the repository of the reconciled resource is checked out to,
+// and a function to clean it up after use. Without a ManifestCacheDir, a temporary directory is used.
+func (r *SampleReconciler) gitCheckoutDir(objectInstance *v1alpha1.Sample) (string, func(), error) {
+	if r.ManifestCacheDir == "" {
+		tmpDir, err := os.MkdirTemp("", "git-checkout-")
+		if err != nil {
+			return "", nil, fmt.Errorf("checkout directory could not be created: %w", err)
+		}
+		return tmpDir, func() { _ = os.RemoveAll(tmpDir) }, nil
+	}
+
+	checkoutDir := r.gitCacheDir(objectInstance)
+	if err := os.MkdirAll(checkoutDir, os.ModePerm); err != nil {
+		return "", nil, fmt.Errorf("checkout directory %s could not be created: %w", checkoutDir, err)
+	}
+	return checkoutDir, func() {}, nil
+}
+
+// cleanupManifestCache removes the cached checkout of the repository of the reconciled resource, if any,
+// and drops the cached files and watches of its manifest, if no other Sample references them.
+// Failures are only logged, the cache directory is not required for the deletion of the reconciled resource.
+func (r *SampleReconciler) cleanupManifestCache(ctx context.Context, objectInstance *v1alpha1.Sample) {
+	r.useManifestPath(objectInstance, "")
+	r.gitFetches.forget(client.ObjectKeyFromObject(objectInstance))
+	if r.ManifestCacheDir == "" {
+		return
+	}
+	if err := os.RemoveAll(r.gitCacheDir(objectInstance)); err != nil {
+		log.FromContext(ctx).Error(err, "error removing cached git checkout")
+	}
+}
+
+func (r *SampleReconciler) gitCacheDir(objectInstance *v1alpha1.Sample) string {
+	return filepath.Join(r.ManifestCacheDir, gitCheckoutsDir, string(objectInstance.GetUID()))
+}
+
+// openGitRepository opens the repository checked out at checkoutDir, or initializes it if it does not exist yet.
+// The origin remote of the repository always points to url.
+func openGitRepository(checkoutDir, url string) (*git.Repository, error) {
+	repo, err := git.PlainOpen(checkoutDir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		repo, err = git.PlainInit(checkoutDir, false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("git repository at %s could not be opened: %w", checkoutDir, err)
+	}
+
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	switch {
+	case err == nil && len(remote.Config().URLs) == 1 && remote.Config().URLs[0] == url:
+		return repo, nil
+	case err == nil:
+		if err = repo.DeleteRemote(git.DefaultRemoteName); err != nil {
+			return nil, fmt.Errorf("outdated remote of git repository at %s could not be removed: %w",
+				checkoutDir, err)
+		}
+	case !errors.Is(err, git.ErrRemoteNotFound):
+		return nil, fmt.Errorf("remote of git repository at %s could not be read: %w", checkoutDir, err)
+	}
+
+	if _, err = repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{url}}); err != nil {
+		return nil, fmt.Errorf("remote %s could not be configured: %w", url, err)
+	}
+	return repo, nil
+}
+
+// gitFetches records for which spec.source.git and when the repository of each reconciled resource was last
+// fetched. They are kept in memory only, so all repositories are fetched again after a restart.
+type gitFetches struct {
+	mu      sync.Mutex
+	fetches map[types.NamespacedName]gitFetch
+}
+
+type gitFetch struct {
+	source    v1alpha1.GitSource
+	fetchedAt time.Time
+}
+
+// due reports whether the repository of the resource with key has to be fetched, because it was not fetched
+// for source yet or the last fetch is older than resyncInterval. A resyncInterval of 0 disables refetches.
+func (g *gitFetches) due(key types.NamespacedName, source v1alpha1.GitSource, resyncInterval time.Duration) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	last, found := g.fetches[key]
+	return !found || last.source != source || (resyncInterval > 0 && time.Since(last.fetchedAt) >= resyncInterval)
+}
+
+func (g *gitFetches) set(key types.NamespacedName, source v1alpha1.GitSource) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.fetches == nil {
+		g.fetches = make(map[types.NamespacedName]gitFetch)
+	}
+	g.fetches[key] = gitFetch{source: source, fetchedAt: time.Now()}
+}
+
+func (g *gitFetches) forget(key types.NamespacedName) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.fetches, key)
+}
+
+// fetchGitRevision returns the commit the branch, tag or commit of gitSource resolves to. The branches and tags
+// of the remote are fetched before, if fetch is set or the revision is not present yet. A commit which is
+// already present is never fetched again.
+func fetchGitRevision(ctx context.Context, repo *git.Repository, gitSource *v1alpha1.GitSource, fetch bool,
+) (plumbing.Hash, error) {
+	if gitSource.Commit != "" {
+		if _, err := repo.CommitObject(plumbing.NewHash(gitSource.Commit)); err == nil {
+			return plumbing.NewHash(gitSource.Commit), nil
+		}
+	} else if !fetch {
+		if commit, err := resolveGitRevision(repo, gitSource); err == nil {
+			return commit, nil
+		}
+	}
+
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", git.DefaultRemoteName)),
+			"+refs/tags/*:refs/tags/*",
+		},
+		Force: true,
+	})
+	if errors.Is(err, transport.ErrRepositoryNotFound) {
+		return plumbing.ZeroHash, fmt.Errorf("%w: git repository %s does not exist, check spec.source.git.url",
+			errManifestNotFound, gitSource.URL)
+	}
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return plumbing.ZeroHash, fmt.Errorf("git repository %s could not be fetched: %w", gitSource.URL, err)
+	}
+	return resolveGitRevision(repo, gitSource)
+}
+
+func resolveGitRevision(repo *git.Repository, gitSource *v1alpha1.GitSource) (plumbing.Hash, error) {
+	var revision plumbing.Revision
+	switch {
+	case gitSource.Branch != "":
+		revision = plumbing.Revision(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, gitSource.Branch))
+	case gitSource.Tag != "":
+		revision = plumbing.Revision(plumbing.NewTagReferenceName(gitSource.Tag))
+	default:
+		revision = plumbing.Revision(gitSource.Commit)
+	}
+	commit, err := repo.ResolveRevision(revision)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("%w: revision %s not found in git repository %s: %w",
+			errManifestNotFound, gitRevisionName(gitSource), gitSource.URL, err)
+	}
+	return *commit, nil
+}
+
+func gitRevisionName(gitSource *v1alpha1.GitSource) string {
+	switch {
+	case gitSource.Branch != "":
+		return "branch " + gitSource.Branch
+	case gitSource.Tag != "":
+		return "tag " + gitSource.Tag
+	default:
+		return "commit " + gitSource.Commit
+	}
+}
+
+// gitResourcePath returns the resolved path of the manifest in the checkout, which must not point outside of it,
+// also not by symbolic links committed to the repository.
+func gitResourcePath(checkoutDir string, gitSource *v1alpha1.GitSource) (string, error) {
+	resolvedCheckoutDir, err := filepath.EvalSymlinks(checkoutDir)
+	if err != nil {
+		return "", fmt.Errorf("checkout directory %s could not be resolved: %w", checkoutDir, err)
+	}
+	resourcePath, err := filepath.EvalSymlinks(filepath.Join(resolvedCheckoutDir, gitSource.Path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: path %s does not exist in git repository %s, check spec.source.git.path",
+			errManifestNotFound, gitSource.Path, gitSource.URL)
+	}
+	if err != nil {
+		return "", fmt.Errorf("path %s in git repository %s could not be resolved: %w",
+			gitSource.Path, gitSource.URL, err)
+	}
+	relPath, err := filepath.Rel(resolvedCheckoutDir, resourcePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) ||
+		relPath == git.GitDirName || strings.HasPrefix(relPath, git.GitDirName+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: path %s points outside of git repository %s, check spec.source.git.path",
+			errManifestNotFound, gitSource.Path, gitSource.URL)
+	}
+	return resourcePath, nil
+}
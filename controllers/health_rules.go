@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/lru"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+const (
+	// healthRuleCostLimit limits the cost of a single evaluation of a health rule,
+	// so a rule cannot block the reconciliation of a Sample.
+	healthRuleCostLimit = 1000000
+	// healthRuleProgramCacheSize limits the compiled programs of the health rules of Samples,
+	// which are kept for later reconciliations.
+	healthRuleProgramCacheSize = 256
+)
+
+var errHealthRuleInvalid = errors.New("health rule is invalid")
+
+//nolint:gochecknoglobals
+var healthRuleEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(cel.Variable("self", cel.DynType))
+})
+
+// healthRules are the health rules applying to the resources of a Sample, by group and kind.
+type healthRules map[schema.GroupKind][]compiledHealthRule
+
+type compiledHealthRule struct {
+	v1alpha1.HealthRule
+	program cel.Program
+}
+
+// LoadHealthRules reads the health rules of the operator from a YAML list of rules at path,
+// and ensures that all expressions compile.
+func LoadHealthRules(path string) ([]v1alpha1.HealthRule, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("health rules could not be read from %s: %w", path, err)
+	}
+	var rules []v1alpha1.HealthRule
+	if err = yaml.UnmarshalStrict(content, &rules); err != nil {
+		return nil, fmt.Errorf("health rules could not be parsed from %s: %w", path, err)
+	}
+	if _, err = compileHealthRules(rules, path, nil); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// healthRulesFor returns the health rules for the resources of the reconciled resource. Rules of its spec
+// take precedence over the rules of the operator for the same group and kind.
+func (r *SampleReconciler) healthRulesFor(objectInstance *v1alpha1.Sample) (healthRules, error) {
+	rules, err := compileHealthRules(objectInstance.Spec.HealthRules, "spec.healthRules", r.healthRulePrograms)
+	if err != nil {
+		return nil, err
+	}
+	for groupKind, kindRules := range r.operatorHealthRules {
+		if _, found := rules[groupKind]; !found {
+			rules[groupKind] = kindRules
+		}
+	}
+	return rules, nil
+}
+
+// compileHealthRules compiles rules, reusing the programs of expressions cached in programs.
+// With nil programs, all rules are compiled.
+func compileHealthRules(rules []v1alpha1.HealthRule, location string, programs *lru.Cache) (healthRules, error) {
+	compiled := healthRules{}
+	for _, rule := range rules {
+		program, err := compileHealthRule(rule.Expression, programs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rule %q for %s in %s: %w",
+				errHealthRuleInvalid, rule.Expression, rule.Kind, location, err)
+		}
+		groupKind := schema.GroupKind{Group: rule.Group, Kind: rule.Kind}
+		compiled[groupKind] = append(compiled[groupKind], compiledHealthRule{HealthRule: rule, program: program})
+	}
+	return compiled, nil
+}
+
+// compileHealthRule compiles expression into a program, which is added to programs for later reconciliations.
+// The least recently used programs are evicted, as expressions are defined by users in the spec of Samples.
+func compileHealthRule(expression string, programs *lru.Cache) (cel.Program, error) {
+	if programs != nil {
+		if program, found := programs.Get(expression); found {
+			return program.(cel.Program), nil //nolint:forcetypeassert // only programs are added
+		}
+	}
+
+	env, err := healthRuleEnv()
+	if err != nil {
+		return nil, fmt.Errorf("CEL environment could not be created: %w", err)
+	}
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if outputType := ast.OutputType(); outputType != cel.BoolType && outputType != cel.DynType {
+		return nil, fmt.Errorf("expression evaluates to %s, expected bool", outputType)
+	}
+	program, err := env.Program(ast, cel.CostLimit(healthRuleCostLimit))
+	if err != nil {
+		return nil, err
+	}
+	if programs != nil {
+		programs.Add(expression, program)
+	}
+	return program, nil
+}
+
+// resourceHealth determines the health of an applied resource by all rules for its group, version and kind,
+// or by the built-in health evaluation if there is no such rule.
+func (rules healthRules) resourceHealth(obj *unstructured.Unstructured) (v1alpha1.ResourceHealth, string) {
+	gvk := obj.GroupVersionKind()
+	matched := false
+	for _, rule := range rules[gvk.GroupKind()] {
+		if rule.Version != "" && rule.Version != gvk.Version {
+			continue
+		}
+		matched = true
+		healthy, err := rule.evaluate(obj)
+		if err != nil {
+			return v1alpha1.ResourceHealthProgressing,
+				fmt.Sprintf("health rule %q could not be evaluated: %v", rule.Expression, err)
+		}
+		if !healthy {
+			return v1alpha1.ResourceHealthProgressing, fmt.Sprintf("health rule %q is not satisfied", rule.Expression)
+		}
+	}
+	if !matched {
+		return resourceHealth(obj)
+	}
+	return v1alpha1.ResourceHealthHealthy, ""
+}
+
+func (rule compiledHealthRule) evaluate(obj *unstructured.Unstructured) (bool, error) {
+	result, _, err := rule.program.Eval(map[string]interface{}{"self": obj.Object})
+	if err != nil {
+		return false, err
+	}
+	healthy, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %v, expected bool", result.Value())
+	}
+	return healthy, nil
+}
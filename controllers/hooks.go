@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+const (
+	// hookAnnotation marks a Job or Pod of the manifest as hook, with a comma separated list of the hook types
+	// it runs for. Hooks are not applied with the other resources, but run in their phase of the installation.
+	hookAnnotation = "operator.kyma-project.io/hook"
+	// hookDeletePolicyAnnotation is a comma separated list of hook delete policies, before-hook-creation by default.
+	hookDeletePolicyAnnotation = "operator.kyma-project.io/hook-delete-policy"
+)
+
+// hookType is the phase of the installation a hook runs in.
+type hookType string
+
+const (
+	// hookTypePreInstall hooks run before the resources of the first installation are applied.
+	hookTypePreInstall hookType = "pre-install"
+	// hookTypePostInstall hooks run once after the resources of the first installation are ready.
+	hookTypePostInstall hookType = "post-install"
+	// hookTypePreUpgrade hooks run before the resources of a new generation of an installed Sample are applied.
+	hookTypePreUpgrade hookType = "pre-upgrade"
+	// hookTypePreDelete hooks run before the resources of a deleted Sample are deleted.
+	hookTypePreDelete hookType = "pre-delete"
+	// hookTypePostDelete hooks run after the resources of a deleted Sample are gone.
+	hookTypePostDelete hookType = "post-delete"
+)
+
+// hookDeletePolicy defines when a hook is deleted.
+type hookDeletePolicy string
+
+const (
+	// hookDeletePolicyBeforeCreation deletes the hook of a previous run before the hook is created again.
+	hookDeletePolicyBeforeCreation hookDeletePolicy = "before-hook-creation"
+	// hookDeletePolicySucceeded deletes the hooks of a phase once all of them succeeded.
+	hookDeletePolicySucceeded hookDeletePolicy = "hook-succeeded"
+	// hookDeletePolicyFailed deletes the hooks of a phase once one of them failed or they timed out.
+	hookDeletePolicyFailed hookDeletePolicy = "hook-failed"
+)
+
+// hookResult is the result of a single hook.
+type hookResult int
+
+const (
+	hookResultRunning hookResult = iota
+	hookResultSucceeded
+	hookResultFailed
+)
+
+var (
+	errHookFailed  = errors.New("hook failed")
+	errHookInvalid = errors.New("hook is invalid")
+)
+
+//nolint:gochecknoglobals
+var (
+	hookConditionTypes = map[hookType]string{
+		hookTypePreInstall:  v1alpha1.ConditionTypePreInstallHooks,
+		hookTypePostInstall: v1alpha1.ConditionTypePostInstallHooks,
+		hookTypePreUpgrade:  v1alpha1.ConditionTypePreUpgradeHooks,
+		hookTypePreDelete:   v1alpha1.ConditionTypePreDeleteHooks,
+		hookTypePostDelete:  v1alpha1.ConditionTypePostDeleteHooks,
+	}
+
+	hookDeletePolicies = []hookDeletePolicy{
+		hookDeletePolicyBeforeCreation, hookDeletePolicySucceeded, hookDeletePolicyFailed,
+	}
+
+	hookGroupKinds = []schema.GroupKind{{Kind: "Pod"}, {Group: "batch", Kind: "Job"}}
+)
+
+// splitHooks separates the hooks of objs from the resources which are applied.
+func splitHooks(objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, []*unstructured.Unstructured) {
+	resources := make([]*unstructured.Unstructured, 0, len(objs))
+	hooks := make([]*unstructured.Unstructured, 0)
+	for _, obj := range objs {
+		if _, found := obj.GetAnnotations()[hookAnnotation]; found {
+			hooks = append(hooks, obj)
+			continue
+		}
+		resources = append(resources, obj)
+	}
+	return resources, hooks
+}
+
+// validateHooks returns an error for the first hook which is not a Job or Pod,
+// or which has an unknown hook type or delete policy.
+func validateHooks(hooks []*unstructured.Unstructured) error {
+	for _, hook := range hooks {
+		if !slices.Contains(hookGroupKinds, hook.GroupVersionKind().GroupKind()) {
+			return fmt.Errorf("%w: %s has annotation %s, but only Jobs and Pods can be hooks",
+				errHookInvalid, inventoryEntryOf(hook), hookAnnotation)
+		}
+		for _, value := range annotationValues(hook, hookAnnotation) {
+			if _, found := hookConditionTypes[hookType(value)]; !found {
+				return fmt.Errorf("%w: %s has unknown hook type %q", errHookInvalid, inventoryEntryOf(hook), value)
+			}
+		}
+		for _, value := range annotationValues(hook, hookDeletePolicyAnnotation) {
+			if !slices.Contains(hookDeletePolicies, hookDeletePolicy(value)) {
+				return fmt.Errorf("%w: %s has unknown hook delete policy %q",
+					errHookInvalid, inventoryEntryOf(hook), value)
+			}
+		}
+	}
+	return nil
+}
+
+// hooksOfType returns the hooks which run for hookType.
+func hooksOfType(hooks []*unstructured.Unstructured, hookType hookType) []*unstructured.Unstructured {
+	matching := make([]*unstructured.Unstructured, 0)
+	for _, hook := range hooks {
+		if slices.Contains(annotationValues(hook, hookAnnotation), string(hookType)) {
+			matching = append(matching, hook)
+		}
+	}
+	return matching
+}
+
+func hasHookDeletePolicy(hook *unstructured.Unstructured, policy hookDeletePolicy) bool {
+	policies := annotationValues(hook, hookDeletePolicyAnnotation)
+	if len(policies) == 0 {
+		return policy == hookDeletePolicyBeforeCreation
+	}
+	return slices.Contains(policies, string(policy))
+}
+
+func annotationValues(obj *unstructured.Unstructured, annotation string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(obj.GetAnnotations()[annotation], ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// runHooks runs the hooks of hookType for the current generation of the reconciled resource and reports
+// their progress in the condition of hookType. It returns true once all hooks succeeded,
+// and an error if a hook failed or the hooks did not complete within HookTimeout.
+// Hooks which failed for a generation are only run again for a new generation.
+func (r *SampleReconciler) runHooks(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus, hookType hookType, hooks []*unstructured.Unstructured,
+) (bool, error) {
+	hooks = hooksOfType(hooks, hookType)
+	if len(hooks) == 0 {
+		return true, nil
+	}
+	conditionType := hookConditionTypes[hookType]
+	generation := objectInstance.GetGeneration()
+
+	condition := meta.FindStatusCondition(status.Conditions, conditionType)
+	if condition == nil || condition.ObservedGeneration != generation ||
+		condition.Reason == v1alpha1.ConditionReasonHooksPending {
+		started, err := r.startHooks(ctx, hooks)
+		if err != nil {
+			return false, err
+		}
+		if !started {
+			setHookCondition(status, hookType, generation, metav1.ConditionUnknown, v1alpha1.ConditionReasonHooksPending,
+				fmt.Sprintf("%s hooks are waiting for the hooks of a previous run to be deleted: %s",
+					hookType, describeObjects(hooks)))
+			return false, nil
+		}
+		// the condition is recreated, so the timeout starts with the creation of the hooks
+		meta.RemoveStatusCondition(&status.Conditions, conditionType)
+		setHookCondition(status, hookType, generation, metav1.ConditionUnknown, v1alpha1.ConditionReasonHooksRunning,
+			fmt.Sprintf("%s hooks are running: %s", hookType, describeObjects(hooks)))
+		r.Event(objectInstance, "Normal", v1alpha1.ConditionReasonHooksRunning, fmt.Sprintf("running %s hooks", hookType))
+		return false, nil
+	}
+
+	switch condition.Status {
+	case metav1.ConditionTrue:
+		return true, nil
+	case metav1.ConditionFalse:
+		return false, fmt.Errorf("%w: %s", errHookFailed, condition.Message)
+	}
+
+	failed := make([]string, 0)
+	running := make([]*unstructured.Unstructured, 0)
+	for _, hook := range hooks {
+		result, message, err := r.hookResultOf(ctx, hook)
+		if err != nil {
+			return false, err
+		}
+		switch result {
+		case hookResultFailed:
+			failed = append(failed, fmt.Sprintf("%s (%s)", inventoryEntryOf(hook), message))
+		case hookResultRunning:
+			running = append(running, hook)
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		// hooks deleted after they succeeded are already gone, while the cached status may not show their success yet
+		succeeded, err := r.hooksSucceeded(ctx, objectInstance, status, conditionType)
+		if err != nil || succeeded {
+			return succeeded, err
+		}
+		message := fmt.Sprintf("%s hooks failed: %s", hookType, strings.Join(failed, ", "))
+		setHookCondition(status, hookType, generation, metav1.ConditionFalse, v1alpha1.ConditionReasonHookFailed, message)
+		return false, errors.Join(fmt.Errorf("%w: %s", errHookFailed, message),
+			r.deleteHooks(ctx, hooks, hookDeletePolicyFailed))
+	case len(running) == 0:
+		setHookCondition(status, hookType, generation, metav1.ConditionTrue, v1alpha1.ConditionReasonHooksSucceeded,
+			fmt.Sprintf("%s hooks succeeded", hookType))
+		r.Event(objectInstance, "Normal", v1alpha1.ConditionReasonHooksSucceeded,
+			fmt.Sprintf("%s hooks succeeded", hookType))
+		return true, r.deleteHooks(ctx, hooks, hookDeletePolicySucceeded)
+	case r.HookTimeout > 0 && time.Since(condition.LastTransitionTime.Time) > r.HookTimeout:
+		message := fmt.Sprintf("%s hooks did not complete within %s: %s",
+			hookType, r.HookTimeout, describeObjects(running))
+		setHookCondition(status, hookType, generation, metav1.ConditionFalse, v1alpha1.ConditionReasonHookTimeout, message)
+		return false, errors.Join(fmt.Errorf("%w: %s", errHookFailed, message),
+			r.deleteHooks(ctx, hooks, hookDeletePolicyFailed))
+	}
+	return false, nil
+}
+
+// hooksSucceeded reports whether the hooks of conditionType already succeeded for the current generation according
+// to the reconciled resource in the API server, and sets their condition in status if they did.
+func (r *SampleReconciler) hooksSucceeded(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus, conditionType string,
+) (bool, error) {
+	current := &v1alpha1.Sample{}
+	if err := r.apiReader.Get(ctx, client.ObjectKeyFromObject(objectInstance), current); err != nil {
+		return false, fmt.Errorf("status of hooks could not be read: %w", err)
+	}
+	condition := meta.FindStatusCondition(current.Status.Conditions, conditionType)
+	if condition == nil || condition.ObservedGeneration != objectInstance.GetGeneration() ||
+		condition.Status != metav1.ConditionTrue {
+		return false, nil
+	}
+	meta.SetStatusCondition(&status.Conditions, *condition)
+	return true, nil
+}
+
+// hooksExcept returns the hooks which do not run for hookType.
+func hooksExcept(hooks []*unstructured.Unstructured, hookType hookType) []*unstructured.Unstructured {
+	others := make([]*unstructured.Unstructured, 0, len(hooks))
+	for _, hook := range hooks {
+		if !slices.Contains(annotationValues(hook, hookAnnotation), string(hookType)) {
+			others = append(others, hook)
+		}
+	}
+	return others
+}
+
+// runningHooks describes the hooks of the current generation which are still running,
+// or returns an empty string if there are none.
+func runningHooks(status *v1alpha1.SampleStatus, objGeneration int64) string {
+	messages := make([]string, 0)
+	for _, hookType := range []hookType{
+		hookTypePreInstall, hookTypePreUpgrade, hookTypePostInstall, hookTypePreDelete, hookTypePostDelete,
+	} {
+		condition := meta.FindStatusCondition(status.Conditions, hookConditionTypes[hookType])
+		if condition != nil && condition.Status == metav1.ConditionUnknown &&
+			condition.ObservedGeneration == objGeneration {
+			messages = append(messages, condition.Message)
+		}
+	}
+	return strings.Join(messages, ", ")
+}
+
+func setHookCondition(status *v1alpha1.SampleStatus, hookType hookType, objGeneration int64,
+	conditionStatus metav1.ConditionStatus, reason, message string,
+) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               hookConditionTypes[hookType],
+		Status:             conditionStatus,
+		ObservedGeneration: objGeneration,
+		Reason:             reason,
+		Message:            truncateMessage(message),
+	})
+}
+
+// startHooks creates hooks, after the hooks of a previous run with the before-hook-creation delete policy are gone.
+// It returns false while waiting for hooks of a previous run to be deleted.
+func (r *SampleReconciler) startHooks(ctx context.Context, hooks []*unstructured.Unstructured) (bool, error) {
+	deleting := false
+	for _, hook := range hooks {
+		if !hasHookDeletePolicy(hook, hookDeletePolicyBeforeCreation) {
+			continue
+		}
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(hook.GroupVersionKind())
+		err := r.Get(ctx, client.ObjectKeyFromObject(hook), current)
+		if errors2.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("hook %s could not be read: %w", inventoryEntryOf(hook), err)
+		}
+		deleting = true
+		if current.GetDeletionTimestamp().IsZero() {
+			err = r.Delete(ctx, current, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if client.IgnoreNotFound(err) != nil {
+				return false, fmt.Errorf("hook %s of a previous run could not be deleted: %w", inventoryEntryOf(hook), err)
+			}
+		}
+	}
+	if deleting {
+		return false, nil
+	}
+
+	for _, hook := range hooks {
+		if err := r.ssa(ctx, hook.DeepCopy()); err != nil {
+			return false, fmt.Errorf("hook %s could not be created: %w", inventoryEntryOf(hook), err)
+		}
+	}
+	return true, nil
+}
+
+// hookResultOf reads the result of a Job from its Complete and Failed conditions, and of a Pod from its phase.
+func (r *SampleReconciler) hookResultOf(ctx context.Context, hook *unstructured.Unstructured,
+) (hookResult, string, error) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(hook.GroupVersionKind())
+	err := r.Get(ctx, client.ObjectKeyFromObject(hook), current)
+	if errors2.IsNotFound(err) {
+		// the cache may not contain a hook which was just created, so it is only failed if the API server
+		// does not know it either
+		err = r.apiReader.Get(ctx, client.ObjectKeyFromObject(hook), current)
+	}
+	if errors2.IsNotFound(err) {
+		return hookResultFailed, "hook was deleted before it completed", nil
+	}
+	if err != nil {
+		return hookResultRunning, "", fmt.Errorf("hook %s could not be read: %w", inventoryEntryOf(hook), err)
+	}
+
+	if hook.GetKind() == "Pod" {
+		phase, _, _ := unstructured.NestedString(current.Object, "status", "phase")
+		switch phase {
+		case "Succeeded":
+			return hookResultSucceeded, "", nil
+		case "Failed":
+			return hookResultFailed, "phase is Failed", nil
+		}
+		return hookResultRunning, "", nil
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(current.Object, "status", "conditions")
+	if condition := findCondition(conditions, "Complete"); condition != nil && condition.status == "True" {
+		return hookResultSucceeded, "", nil
+	}
+	if condition := findCondition(conditions, "Failed"); condition != nil && condition.status == "True" {
+		return hookResultFailed, condition.describe(), nil
+	}
+	return hookResultRunning, "", nil
+}
+
+// deleteHooks deletes the hooks with the given delete policy.
+func (r *SampleReconciler) deleteHooks(ctx context.Context, hooks []*unstructured.Unstructured,
+	policy hookDeletePolicy,
+) error {
+	for _, hook := range hooks {
+		if !hasHookDeletePolicy(hook, policy) {
+			continue
+		}
+		err := r.Delete(ctx, hook.DeepCopy(), client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("hook %s could not be deleted: %w", inventoryEntryOf(hook), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// pruneAnnotation excludes a resource from pruning if set to "false".
+const pruneAnnotation = "operator.kyma-project.io/prune"
+
+// inventoryKey identifies a resource independent of the version it was applied with.
+type inventoryKey struct {
+	group, kind, namespace, name string
+}
+
+func keyOf(entry v1alpha1.InventoryEntry) inventoryKey {
+	return inventoryKey{group: entry.Group, kind: entry.Kind, namespace: entry.Namespace, name: entry.Name}
+}
+
+// newInventory returns the inventory of the passed resources in the order of the manifest, without duplicates.
+func newInventory(objs []*unstructured.Unstructured) []v1alpha1.InventoryEntry {
+	inventory := make([]v1alpha1.InventoryEntry, 0, len(objs))
+	seen := sets.New[inventoryKey]()
+	for _, obj := range objs {
+		entry := inventoryEntryOf(obj)
+		if seen.Has(keyOf(entry)) {
+			continue
+		}
+		seen.Insert(keyOf(entry))
+		inventory = append(inventory, entry)
+	}
+	return inventory
+}
+
+// checkInventorySize rejects an inventory with more entries than the status of a Sample
+// and the Managed resource can list.
+func checkInventorySize(entries int) error {
+	if entries > v1alpha1.MaxInventoryEntries {
+		return fmt.Errorf("%w: %d resources exceed the maximum of %d resources of a Sample, "+
+			"split the manifest across multiple Samples", errManifestTooLarge, entries, v1alpha1.MaxInventoryEntries)
+	}
+	return nil
+}
+
+// withRetainedEntries returns current with the entries of previous appended which are not part of current.
+// It is used while documents of the manifest are skipped, as the resources of the previous inventory
+// may only be missing because their documents could not be parsed, so they are neither pruned nor forgotten.
+func withRetainedEntries(previous, current []v1alpha1.InventoryEntry) []v1alpha1.InventoryEntry {
+	seen := sets.New[inventoryKey]()
+	for _, entry := range current {
+		seen.Insert(keyOf(entry))
+	}
+	for _, entry := range previous {
+		if !seen.Has(keyOf(entry)) {
+			current = append(current, entry)
+		}
+	}
+	return current
+}
+
+func inventoryEntryOf(obj *unstructured.Unstructured) v1alpha1.InventoryEntry {
+	gvk := obj.GroupVersionKind()
+	return v1alpha1.InventoryEntry{
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Kind:      gvk.Kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+}
+
+// pruneResources deletes all resources of the previous inventory which are not part of the current inventory.
+// Nothing is pruned if pruning is disabled for the reconciled resource, and resources annotated with
+// operator.kyma-project.io/prune: "false" are left in place.
+func (r *SampleReconciler) pruneResources(ctx context.Context, objectInstance *v1alpha1.Sample,
+	previous, current []v1alpha1.InventoryEntry,
+) error {
+	if objectInstance.Spec.Prune != nil && !*objectInstance.Spec.Prune {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	retained := sets.New[inventoryKey]()
+	for _, entry := range current {
+		retained.Insert(keyOf(entry))
+	}
+
+	for _, entry := range previous {
+		if retained.Has(keyOf(entry)) {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: entry.Group, Version: entry.Version, Kind: entry.Kind})
+		err := r.Get(ctx, client.ObjectKey{Namespace: entry.Namespace, Name: entry.Name}, obj)
+		if errors2.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s could not be read for pruning: %w", entry, err)
+		}
+		if obj.GetAnnotations()[pruneAnnotation] == "false" {
+			logger.V(debugLogLevel).Info("skipping pruning of annotated resource", "resource", entry.String())
+			continue
+		}
+
+		err = r.Delete(ctx, obj, client.PropagationPolicy(deletionPropagation(objectInstance)))
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("%s could not be pruned: %w", entry, err)
+		}
+		r.Event(objectInstance, "Normal", "ResourcesPrune", fmt.Sprintf("pruned %s", entry))
+	}
+	return nil
+}
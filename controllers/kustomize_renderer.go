@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// isKustomization checks if the passed dirPath contains a kustomization file.
+func isKustomization(dirPath string) bool {
+	for _, fileName := range konfig.RecognizedKustomizationFileNames() {
+		if info, err := os.Stat(filepath.Join(dirPath, fileName)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// buildKustomization runs a kustomize build in-process for the kustomization located at dirPath
+// and returns the resources in unstructured format.
+func buildKustomization(dirPath string) (*ManifestResources, error) {
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resMap, err := kustomizer.Run(filesys.MakeFsOnDisk(), dirPath)
+	if err != nil {
+		return nil, &renderError{err: fmt.Errorf("kustomization %s could not be built: %w", dirPath, err)}
+	}
+
+	manifest, err := resMap.AsYaml()
+	if err != nil {
+		return nil, &renderError{err: fmt.Errorf("kustomization %s could not be converted to yaml: %w", dirPath, err)}
+	}
+	return parseManifestStringToObjects(dirPath, string(manifest)), nil
+}
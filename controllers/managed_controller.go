@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// managedResourcesIndex indexes Managed objects by the resources of their spec.
+const managedResourcesIndex = "spec.resources"
+
+// ManagedReconciler reconciles a Managed object.
+type ManagedReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// EventRecorder for creating k8s events
+	record.EventRecorder
+
+	watches *resourceWatches
+}
+
+// +kubebuilder:rbac:groups=operator.kyma-project.io,resources=manageds,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=operator.kyma-project.io,resources=manageds/status,verbs=get;update;patch
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ManagedReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.Managed{},
+		managedResourcesIndex, indexManagedResources); err != nil {
+		return fmt.Errorf("failed to index resources of managed objects: %w", err)
+	}
+
+	// the resources of the spec are watched dynamically once their kinds are known
+	managedController, err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.Managed{}).
+		Build(r)
+	if err != nil {
+		return fmt.Errorf("failed to build controller: %w", err)
+	}
+	r.watches = newResourceWatches(managedController, mgr.GetCache(), r.requestsForManagedResource)
+	return nil
+}
+
+// Reconcile sets the state of a Managed object based on the existence of the resources of its spec.
+// It is Ready if all resources exist, and Warning otherwise. Only the metadata of the resources is read
+// from the cache, and the kinds of the resources are watched, so changes are reconciled without polling.
+func (r *ManagedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	managed := v1alpha1.Managed{}
+	if err := r.Get(ctx, req.NamespacedName, &managed); err != nil {
+		logger.Info(req.NamespacedName.String() + " got deleted!")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !managed.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	status := *managed.Status.DeepCopy()
+	missing := make([]v1alpha1.InventoryEntry, 0)
+	for _, entry := range managed.Spec.Resources {
+		exists, err := r.resourceExists(ctx, entry)
+		if err != nil {
+			r.Event(&managed, "Warning", "ResourcesUnavailable", err.Error())
+			return ctrl.Result{RequeueAfter: requeueInterval},
+				r.setManagedStatus(ctx, &managed, status.WithState(v1alpha1.StateError))
+		}
+		if !exists {
+			missing = append(missing, entry)
+		}
+	}
+
+	status.ResourcesAvailable = fmt.Sprintf("%d/%d", len(managed.Spec.Resources)-len(missing),
+		len(managed.Spec.Resources))
+	status.MissingResources = nil
+	if len(missing) > 0 {
+		status.MissingResources = missing[:min(len(missing), v1alpha1.MaxResourceStatuses)]
+		status.WithState(v1alpha1.StateWarning)
+	} else {
+		status.WithState(v1alpha1.StateReady)
+	}
+	return ctrl.Result{}, r.setManagedStatus(ctx, &managed, &status)
+}
+
+// resourceExists reads the metadata of the resource of entry from the cache and watches the resources of its kind.
+func (r *ManagedReconciler) resourceExists(ctx context.Context, entry v1alpha1.InventoryEntry) (bool, error) {
+	gvk := schema.GroupVersionKind{Group: entry.Group, Version: entry.Version, Kind: entry.Kind}
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(gvk)
+	err := r.Get(ctx, client.ObjectKey{Namespace: entry.Namespace, Name: entry.Name}, obj)
+	if meta.IsNoMatchError(err) {
+		// kinds which are not installed in the cluster are not watched
+		return false, nil
+	}
+	if client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	if watchErr := r.watches.watch(ctx, gvk); watchErr != nil {
+		return false, watchErr
+	}
+	return !errors2.IsNotFound(err), nil
+}
+
+func indexManagedResources(obj client.Object) []string {
+	managed, ok := obj.(*v1alpha1.Managed)
+	if !ok {
+		return nil
+	}
+	indexValues := make([]string, 0, len(managed.Spec.Resources))
+	for _, entry := range managed.Spec.Resources {
+		indexValues = append(indexValues, managedResourceIndexValue(entry.Group, entry.Kind, entry.Namespace, entry.Name))
+	}
+	return indexValues
+}
+
+func managedResourceIndexValue(group, kind, namespace, name string) string {
+	return strings.Join([]string{group, kind, namespace, name}, "/")
+}
+
+// requestsForManagedResource maps resources of gvk to the Managed objects listing them in their spec.
+func (r *ManagedReconciler) requestsForManagedResource(gvk schema.GroupVersionKind,
+) handler.TypedMapFunc[*metav1.PartialObjectMetadata, reconcile.Request] {
+	return func(ctx context.Context, obj *metav1.PartialObjectMetadata) []reconcile.Request {
+		managedList := &v1alpha1.ManagedList{}
+		if err := r.List(ctx, managedList, client.MatchingFields{managedResourcesIndex: managedResourceIndexValue(
+			gvk.Group, gvk.Kind, obj.GetNamespace(), obj.GetName())}); err != nil {
+			log.FromContext(ctx).Error(err, "error listing managed objects of "+obj.GetName())
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(managedList.Items))
+		for i := range managedList.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&managedList.Items[i])})
+		}
+		return requests
+	}
+}
+
+// setManagedStatus sets status on managed if it changed.
+func (r *ManagedReconciler) setManagedStatus(ctx context.Context, managed *v1alpha1.Managed,
+	status *v1alpha1.ManagedStatus,
+) error {
+	if equality.Semantic.DeepEqual(*status, managed.Status) {
+		return nil
+	}
+	managed.Status = *status
+	managed.SetManagedFields(nil)
+	managed.SetResourceVersion("")
+	if err := r.Status().Patch(ctx, managed, client.Apply,
+		&client.SubResourcePatchOptions{PatchOptions: client.PatchOptions{FieldManager: fieldOwner}}); err != nil {
+		return fmt.Errorf("error while updating status %s to: %w", status.State, err)
+	}
+	r.Event(managed, "Normal", "StatusUpdated", fmt.Sprintf("updating state to %v", string(status.State)))
+	return nil
+}
+
+// syncManaged creates or updates the Managed object of the reconciled resource, which has its name and namespace
+// and is controlled by it, with the inventory of its installation.
+func (r *SampleReconciler) syncManaged(ctx context.Context, objectInstance *v1alpha1.Sample,
+	inventory []v1alpha1.InventoryEntry,
+) error {
+	managed := &v1alpha1.Managed{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "Managed",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      objectInstance.GetName(),
+			Namespace: objectInstance.GetNamespace(),
+		},
+		Spec: v1alpha1.ManagedSpec{Resources: inventory},
+	}
+	if err := controllerutil.SetControllerReference(objectInstance, managed, r.Scheme); err != nil {
+		return fmt.Errorf("owner of managed resource %s could not be set: %w", managed.GetName(), err)
+	}
+	if err := r.ssa(ctx, managed); err != nil {
+		return fmt.Errorf("managed resource %s could not be applied: %w", managed.GetName(), err)
+	}
+	return nil
+}
+
+// deleteManaged deletes the Managed object controlled by the reconciled resource,
+// as it is not garbage collected before the finalizer of the reconciled resource is removed.
+func (r *SampleReconciler) deleteManaged(ctx context.Context, objectInstance *v1alpha1.Sample) error {
+	managed := &v1alpha1.Managed{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(objectInstance), managed)
+	if errors2.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("managed resource %s could not be read for deletion: %w", objectInstance.GetName(), err)
+	}
+	if !metav1.IsControlledBy(managed, objectInstance) {
+		return nil
+	}
+	if err = r.Delete(ctx, managed); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("managed resource %s could not be deleted: %w", objectInstance.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// manifestFileCache keeps the parsed resources of local manifest files, keyed by their path.
+// It is shared by all Samples, so files loaded for multiple Samples are only parsed once.
+// A cached file is only read again once its modification time or size changed, and only parsed again
+// once its content changed. A nil manifestFileCache parses files with every load.
+type manifestFileCache struct {
+	mu      sync.Mutex
+	entries map[string]manifestFileEntry
+}
+
+type manifestFileEntry struct {
+	modTime   time.Time
+	size      int64
+	digest    string
+	resources *ManifestResources
+}
+
+func newManifestFileCache() *manifestFileCache {
+	return &manifestFileCache{entries: make(map[string]manifestFileEntry)}
+}
+
+// load returns the resources of the manifest file at filePath in unstructured format.
+// The returned resources are a copy of the cached ones and may be modified by the caller.
+func (c *manifestFileCache) load(filePath string) (*ManifestResources, error) {
+	filePath = filepath.Clean(filePath)
+	if c == nil {
+		content, err := readManifestFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return parseManifestStringToObjects(filePath, string(content)), nil
+	}
+
+	// symbolic links are followed, like the links of a ConfigMap mounted as volume,
+	// errors are reported once the file is read
+	info, statErr := os.Stat(filePath)
+	c.mu.Lock()
+	entry, found := c.entries[filePath]
+	c.mu.Unlock()
+	if found && statErr == nil && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.resources.deepCopy(), nil
+	}
+
+	content, err := readManifestFile(filePath)
+	if err != nil {
+		c.forget(filePath)
+		return nil, err
+	}
+	digest := sha256Digest(content)
+	if !found || entry.digest != digest {
+		entry = manifestFileEntry{digest: digest, resources: parseManifestStringToObjects(filePath, string(content))}
+	}
+	if statErr == nil {
+		entry.modTime, entry.size = info.ModTime(), info.Size()
+	}
+	c.mu.Lock()
+	c.entries[filePath] = entry
+	c.mu.Unlock()
+	return entry.resources.deepCopy(), nil
+}
+
+func (c *manifestFileCache) forget(filePath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, filePath)
+}
+
+// forgetPath removes the cached files at resourcePath, or below it if it is a directory.
+func (c *manifestFileCache) forgetPath(resourcePath string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for filePath := range c.entries {
+		if isPathWithin(filePath, resourcePath) {
+			delete(c.entries, filePath)
+		}
+	}
+}
+
+// manifestPaths tracks the local path each Sample loads its manifest files from, so the cached files
+// and watches of a path are dropped once no Sample references it anymore.
+type manifestPaths struct {
+	mu    sync.Mutex
+	paths map[types.NamespacedName]string
+}
+
+// set records resourcePath as the path of the Sample with key, an empty resourcePath removes the Sample.
+// It returns the previous path of the Sample if no other Sample references it.
+func (p *manifestPaths) set(key types.NamespacedName, resourcePath string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paths == nil {
+		p.paths = make(map[types.NamespacedName]string)
+	}
+	previous, found := p.paths[key]
+	if resourcePath == "" {
+		delete(p.paths, key)
+	} else {
+		p.paths[key] = resourcePath
+	}
+	if !found || previous == resourcePath {
+		return "", false
+	}
+	for _, other := range p.paths {
+		if other == previous {
+			return "", false
+		}
+	}
+	return previous, true
+}
+
+// useManifestPath records the local path the manifest of the reconciled resource is loaded from,
+// and drops the cached files and watches of its previous path if no other Sample references it.
+// An empty resourcePath is used for manifests which are not loaded from cached files.
+func (r *SampleReconciler) useManifestPath(objectInstance *v1alpha1.Sample, resourcePath string) {
+	if resourcePath != "" {
+		resourcePath = filepath.Clean(resourcePath)
+	}
+	if previous, unused := r.manifestPaths.set(client.ObjectKeyFromObject(objectInstance), resourcePath); unused {
+		r.manifestFiles.forgetPath(previous)
+		r.manifestWatcher.unwatch(previous)
+	}
+}
+
+// isPathWithin reports whether filePath is dirPath or a path below it.
+func isPathWithin(filePath, dirPath string) bool {
+	return filePath == dirPath || strings.HasPrefix(filePath, dirPath+string(filepath.Separator))
+}
+
+// deepCopy returns a copy of m whose resources can be modified without modifying the resources of m.
+func (m *ManifestResources) deepCopy() *ManifestResources {
+	resources := &ManifestResources{
+		Items:  make([]*unstructured.Unstructured, 0, len(m.Items)),
+		Blobs:  append([]ManifestBlob(nil), m.Blobs...),
+		Source: m.Source,
+	}
+	for _, item := range m.Items {
+		resources.Items = append(resources.Items, item.DeepCopy())
+	}
+	return resources
+}
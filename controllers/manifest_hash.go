@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// manifestHashOf returns the hash of the spec of the reconciled resource and the resources rendered for it.
+// Resources are only applied again if the hash changed, or if the applied resources changed since they were applied.
+func manifestHashOf(objectInstance *v1alpha1.Sample, objs []*unstructured.Unstructured) (string, error) {
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	if err := encoder.Encode(objectInstance.Spec); err != nil {
+		return "", fmt.Errorf("spec could not be hashed: %w", err)
+	}
+	for _, obj := range objs {
+		if err := encoder.Encode(obj.Object); err != nil {
+			return "", fmt.Errorf("%s could not be hashed: %w", inventoryEntryOf(obj), err)
+		}
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// appliedVersion identifies the state of a resource after it was applied.
+type appliedVersion struct {
+	generation      int64
+	resourceVersion string
+}
+
+func appliedVersionOf(obj metav1.Object) appliedVersion {
+	return appliedVersion{generation: obj.GetGeneration(), resourceVersion: obj.GetResourceVersion()}
+}
+
+// specChanged reports whether a resource changed apart from its status since it was applied in version v.
+// Changes of resources without a generation, like ConfigMaps, are only visible in their resource version.
+func (v appliedVersion) specChanged(current appliedVersion) bool {
+	if v.generation == 0 {
+		return current.resourceVersion != v.resourceVersion
+	}
+	return current.generation != v.generation
+}
+
+// appliedVersions keeps the versions the resources of each reconciled resource had after they were
+// last applied. They are kept in memory only, so all resources are applied again after a restart.
+type appliedVersions struct {
+	mu       sync.Mutex
+	versions map[types.NamespacedName]map[inventoryKey]appliedVersion
+}
+
+func (a *appliedVersions) set(key types.NamespacedName, objs []*unstructured.Unstructured) {
+	versions := make(map[inventoryKey]appliedVersion, len(objs))
+	for _, obj := range objs {
+		versions[keyOf(inventoryEntryOf(obj))] = appliedVersionOf(obj)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.versions == nil {
+		a.versions = make(map[types.NamespacedName]map[inventoryKey]appliedVersion)
+	}
+	a.versions[key] = versions
+}
+
+func (a *appliedVersions) get(key types.NamespacedName, entry v1alpha1.InventoryEntry) (appliedVersion, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	version, found := a.versions[key][keyOf(entry)]
+	return version, found
+}
+
+func (a *appliedVersions) forget(key types.NamespacedName) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.versions, key)
+}
+
+// installationChange describes how the resources of the last installation changed since they were applied.
+type installationChange int
+
+const (
+	installationChanged installationChange = iota
+	// installationStatusChanged means only the status of resources changed, so only their health is evaluated again.
+	installationStatusChanged
+	installationUnchanged
+)
+
+// installationChangeOf reports whether applying the resources again can be skipped. That is the case if
+// the manifest hash did not change, all resources of the last installation are healthy, none of them
+// changed apart from their status since it was applied, based on the generations or, for resources without
+// a generation, the resource versions of the watched resources, and the Managed object still lists them.
+func (r *SampleReconciler) installationChangeOf(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus, hash string,
+) installationChange {
+	if r.watches == nil || status.ManifestHash != hash || len(status.Inventory) == 0 ||
+		!isSyncWaveHealthy(status.Resources) {
+		return installationChanged
+	}
+	change := installationUnchanged
+	key := client.ObjectKeyFromObject(objectInstance)
+	for _, entry := range status.Inventory {
+		applied, found := r.appliedVersions.get(key, entry)
+		if !found {
+			return installationChanged
+		}
+		current, found := r.watches.appliedVersionOf(ctx, entry)
+		if !found || applied.specChanged(current) {
+			return installationChanged
+		}
+		if current != applied {
+			change = installationStatusChanged
+		}
+	}
+	managed := &v1alpha1.Managed{}
+	if err := r.Get(ctx, key, managed); err != nil {
+		return installationChanged
+	}
+	if !metav1.IsControlledBy(managed, objectInstance) ||
+		!equality.Semantic.DeepEqual(managed.Spec.Resources, status.Inventory) {
+		return installationChanged
+	}
+	return change
+}
+
+// refreshResourceStatuses evaluates the health of the resources of the last installation again without
+// applying them, as only their status changed. It returns false if one of them changed apart from its status
+// in the meantime, so the resources need to be applied again.
+func (r *SampleReconciler) refreshResourceStatuses(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus, objs []*unstructured.Unstructured, rules healthRules,
+) (bool, error) {
+	key := client.ObjectKeyFromObject(objectInstance)
+	current := make([]*unstructured.Unstructured, 0, len(objs))
+	resources := make([]v1alpha1.ResourceStatus, 0, len(objs))
+	for _, obj := range objs {
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(obj.GroupVersionKind())
+		err := r.Get(ctx, client.ObjectKeyFromObject(obj), live)
+		if errors2.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("%s could not be read: %w", inventoryEntryOf(obj), err)
+		}
+		applied, found := r.appliedVersions.get(key, inventoryEntryOf(obj))
+		if !found || applied.specChanged(appliedVersionOf(live)) {
+			return false, nil
+		}
+		current = append(current, live)
+		resources = append(resources, newResourceStatus(live, nil, rules))
+	}
+	setResourceStatuses(status, resources)
+	r.appliedVersions.set(key, current)
+	return true, nil
+}
+
+// appliedVersionOf returns the version of a resource from the cache of its watch,
+// if resources of its kind are watched.
+func (w *resourceWatches) appliedVersionOf(ctx context.Context, entry v1alpha1.InventoryEntry) (appliedVersion, bool) {
+	gvk := schema.GroupVersionKind{Group: entry.Group, Version: entry.Version, Kind: entry.Kind}
+	w.mu.Lock()
+	_, watched := w.kinds[gvk]
+	w.mu.Unlock()
+	if !watched {
+		return appliedVersion{}, false
+	}
+	metadata := &metav1.PartialObjectMetadata{}
+	metadata.SetGroupVersionKind(gvk)
+	if err := w.cache.Get(ctx, client.ObjectKey{Namespace: entry.Namespace, Name: entry.Name}, metadata); err != nil {
+		return appliedVersion{}, false
+	}
+	return appliedVersionOf(metadata), true
+}
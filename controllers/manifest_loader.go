@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// loadOptions configures which files of a directory are loaded as manifest and in which order.
+type loadOptions struct {
+	// recursive includes manifest files of all subdirectories.
+	recursive bool
+	// include and exclude contain glob patterns matched against the slash separated path
+	// relative to the directory. Patterns without a slash are matched against the file name only.
+	include []string
+	exclude []string
+	// indexFile is a file relative to the directory listing the manifest files in the order they are loaded.
+	indexFile string
+}
+
+//nolint:gochecknoglobals
+var manifestFileExtensions = sets.NewString(".yaml", ".yml", ".json")
+
+// getResourcesFromLocalPath returns resources from the dirPath in unstructured format.
+// All .yaml, .yml and .json files selected by opts are loaded in lexical order of their paths,
+// or in the order of the index file if one is configured, and merged into one manifest.
+// Files are parsed once and then served from the files cache as long as they are unchanged.
+func getResourcesFromLocalPath(dirPath string, opts loadOptions, files *manifestFileCache,
+	logger logr.Logger,
+) (*ManifestResources, error) {
+	filePaths, unsupportedPaths, err := getManifestFilePaths(dirPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(filePaths) == 0 && len(unsupportedPaths) > 0 {
+		return nil, fmt.Errorf("%w: dir %s contains no %s files but %s",
+			errManifestUnsupportedFormat, dirPath, supportedExtensions(), strings.Join(unsupportedPaths, ", "))
+	}
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("%w: no manifest file found at file path %s, "+
+			"check spec.resourceFilePath and the file selection of the Sample", errManifestNotFound, dirPath)
+	}
+
+	resources := &ManifestResources{}
+	for _, filePath := range filePaths {
+		logger.V(debugLogLevel).Info(fmt.Sprintf("loading manifest file %s from file path %s", filePath, dirPath))
+		fileResources, err := files.load(filepath.Join(dirPath, filepath.FromSlash(filePath)))
+		if err != nil {
+			return nil, err
+		}
+		resources.merge(fileResources)
+	}
+	return resources, nil
+}
+
+// readManifestFile returns the content of the manifest file at filePath.
+func readManifestFile(filePath string) ([]byte, error) {
+	fileBytes, err := os.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: manifest file %s does not exist", errManifestNotFound, filePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("manifest file %s could not be read: %w", filePath, err)
+	}
+	return fileBytes, nil
+}
+
+func supportedExtensions() string {
+	return strings.Join(manifestFileExtensions.List(), ", ")
+}
+
+// getManifestFilePaths returns the slash separated paths relative to dirPath of all manifest files
+// selected by opts, in the order they should be loaded. Selected files in an unsupported format
+// are returned separately.
+func getManifestFilePaths(dirPath string, opts loadOptions) ([]string, []string, error) {
+	if opts.indexFile != "" {
+		filePaths, err := getIndexedFilePaths(dirPath, opts)
+		return filePaths, nil, err
+	}
+
+	filePaths, unsupportedPaths := make([]string, 0), make([]string, 0)
+	err := filepath.WalkDir(dirPath, func(filePath string, entry fs.DirEntry, err error) error {
+		// initial error
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if filePath != dirPath && !opts.recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		selected, err := opts.selects(relPath)
+		if err != nil || !selected {
+			return err
+		}
+		if manifestFileExtensions.Has(filepath.Ext(entry.Name())) {
+			filePaths = append(filePaths, relPath)
+		} else {
+			unsupportedPaths = append(unsupportedPaths, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Strings(filePaths)
+	return filePaths, unsupportedPaths, nil
+}
+
+// getIndexedFilePaths returns the manifest files listed in the index file, one path per line.
+// Empty lines and lines starting with # are ignored.
+func getIndexedFilePaths(dirPath string, opts loadOptions) ([]string, error) {
+	indexBytes, err := os.ReadFile(filepath.Join(dirPath, opts.indexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: index file %s does not exist in dir %s, check spec.indexFile",
+			errManifestNotFound, opts.indexFile, dirPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("index file could not be read %s in dir %s: %w", opts.indexFile, dirPath, err)
+	}
+
+	filePaths := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(indexBytes))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		relPath := path.Clean(filepath.ToSlash(line))
+		if path.IsAbs(relPath) || strings.HasPrefix(relPath, "../") {
+			return nil, fmt.Errorf("index file %s lists %s outside of dir %s", opts.indexFile, line, dirPath)
+		}
+		selected, err := opts.selects(relPath)
+		if err != nil {
+			return nil, err
+		}
+		if !selected {
+			continue
+		}
+		if !manifestFileExtensions.Has(path.Ext(relPath)) {
+			return nil, fmt.Errorf("%w: index file %s lists %s which is not a %s file",
+				errManifestUnsupportedFormat, opts.indexFile, relPath, supportedExtensions())
+		}
+		filePaths = append(filePaths, relPath)
+	}
+	return filePaths, scanner.Err()
+}
+
+// selects checks if the file at relPath is selected by the include and exclude patterns.
+// Exclude patterns take precedence over include patterns, no include patterns select all files.
+func (opts loadOptions) selects(relPath string) (bool, error) {
+	included := len(opts.include) == 0
+	for _, pattern := range opts.include {
+		matched, err := matchPattern(pattern, relPath)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false, nil
+	}
+
+	for _, pattern := range opts.exclude {
+		matched, err := matchPattern(pattern, relPath)
+		if err != nil || matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchPattern(pattern, relPath string) (bool, error) {
+	target := relPath
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(relPath)
+	}
+	matched, err := path.Match(pattern, target)
+	if err != nil {
+		return false, fmt.Errorf("invalid file pattern %s: %w", pattern, err)
+	}
+	return matched, nil
+}
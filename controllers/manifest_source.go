@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// Errors returned while resolving the manifest source of a reconciled resource.
+// Each error is surfaced with a distinct reason in the Installation condition and events.
+var (
+	errManifestNotFound          = errors.New("manifest not found")
+	errManifestAmbiguous         = errors.New("manifest source is ambiguous")
+	errManifestUnsupportedFormat = errors.New("manifest format is not supported")
+	errManifestParse             = errors.New("manifest could not be parsed")
+	errManifestDigestMismatch    = errors.New("manifest digest does not match")
+	errManifestTooLarge          = errors.New("manifest contains too many resources")
+)
+
+// renderError is returned if a manifest could not be rendered from a Helm chart or a kustomization.
+type renderError struct {
+	err error
+}
+
+func (e *renderError) Error() string {
+	return e.err.Error()
+}
+
+func (e *renderError) Unwrap() error {
+	return e.err
+}
+
+// installConditionReason returns the reason of the Installation condition for the passed installation error.
+func installConditionReason(err error) string {
+	var rErr *renderError
+	switch {
+	case errors.Is(err, errManifestNotFound):
+		return v1alpha1.ConditionReasonManifestNotFound
+	case errors.Is(err, errManifestAmbiguous):
+		return v1alpha1.ConditionReasonManifestAmbiguous
+	case errors.Is(err, errManifestUnsupportedFormat):
+		return v1alpha1.ConditionReasonManifestUnsupportedFormat
+	case errors.Is(err, errManifestParse):
+		return v1alpha1.ConditionReasonManifestParseError
+	case errors.Is(err, errManifestDigestMismatch):
+		return v1alpha1.ConditionReasonManifestDigestMismatch
+	case errors.Is(err, errManifestTooLarge):
+		return v1alpha1.ConditionReasonManifestTooLarge
+	case errors.Is(err, errHealthRuleInvalid):
+		return v1alpha1.ConditionReasonHealthRuleInvalid
+	case errors.Is(err, errSyncWaveInvalid):
+		return v1alpha1.ConditionReasonSyncWaveInvalid
+	case errors.Is(err, errHookInvalid):
+		return v1alpha1.ConditionReasonHookInvalid
+	case errors.Is(err, errHookFailed):
+		return v1alpha1.ConditionReasonHookFailed
+	case errors.As(err, &rErr):
+		return v1alpha1.ConditionReasonRenderFailed
+	default:
+		return v1alpha1.ConditionReasonInstallationFailed
+	}
+}
+
+// sourceType is the type of manifest source a resource path resolves to.
+type sourceType string
+
+const (
+	sourceTypeFile          sourceType = "File"
+	sourceTypeDirectory     sourceType = "Directory"
+	sourceTypeHelmChart     sourceType = "HelmChart"
+	sourceTypeKustomization sourceType = "Kustomization"
+	sourceTypeKeyRefs       sourceType = "KeyRefs"
+	sourceTypeURL           sourceType = "URL"
+	sourceTypeOCI           sourceType = "OCI"
+	sourceTypeGit           sourceType = "Git"
+)
+
+// resolveLocalSource determines the type of manifest source the local resourcePath points to.
+func resolveLocalSource(resourcePath string) (sourceType, error) {
+	if resourcePath == "" {
+		return "", fmt.Errorf("%w: no manifest source configured, set spec.resourceFilePath or spec.source", errManifestNotFound)
+	}
+
+	info, err := os.Stat(resourcePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: resource path %s does not exist, check spec.resourceFilePath",
+			errManifestNotFound, resourcePath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("resource path %s could not be accessed: %w", resourcePath, err)
+	}
+
+	if !info.IsDir() {
+		if !manifestFileExtensions.Has(filepath.Ext(resourcePath)) {
+			return "", fmt.Errorf("%w: resource path %s is not a %s file",
+				errManifestUnsupportedFormat, resourcePath, supportedExtensions())
+		}
+		return sourceTypeFile, nil
+	}
+
+	helmChart, kustomization := isHelmChart(resourcePath), isKustomization(resourcePath)
+	switch {
+	case helmChart && kustomization:
+		return "", fmt.Errorf("%w: resource path %s contains both a %s and a kustomization, "+
+			"point spec.resourceFilePath to a directory containing only one of them",
+			errManifestAmbiguous, resourcePath, chartFileName)
+	case helmChart:
+		return sourceTypeHelmChart, nil
+	case kustomization:
+		return sourceTypeKustomization, nil
+	default:
+		return sourceTypeDirectory, nil
+	}
+}
+
+// getManifestResources returns the resources of the reconciled resource in unstructured format.
+// The manifest is loaded either from the local ResourceFilePath or from the configured Source.
+// A manifest without any resources is never returned, instead an error describing the cause is returned.
+func (r *SampleReconciler) getManifestResources(ctx context.Context,
+	objectInstance *v1alpha1.Sample,
+) (*ManifestResources, error) {
+	if objectInstance.Spec.Source == nil {
+		resourceObjs, err := r.getResourcesFromLocalSource(ctx, objectInstance)
+		if err != nil {
+			return nil, err
+		}
+		r.useManifestPath(objectInstance, objectInstance.Spec.ResourceFilePath)
+		return resourceObjs, validateManifestResources(resourceObjs,
+			"resource path "+objectInstance.Spec.ResourceFilePath)
+	}
+
+	if objectInstance.Spec.ResourceFilePath != "" {
+		return nil, fmt.Errorf("%w: both spec.resourceFilePath and spec.source are set, set only one of them",
+			errManifestAmbiguous)
+	}
+	resourceObjs, err := r.getResourcesFromSource(ctx, objectInstance)
+	if err != nil {
+		return nil, err
+	}
+	// only files of cached git checkouts are kept in the manifest file cache
+	cachedPath := ""
+	if objectInstance.Spec.Source.Git != nil && r.ManifestCacheDir != "" {
+		cachedPath = r.gitCacheDir(objectInstance)
+	}
+	r.useManifestPath(objectInstance, cachedPath)
+	return resourceObjs, validateManifestResources(resourceObjs, "spec.source")
+}
+
+// getResourcesFromSource returns the resources of the manifest source configured in spec.source.
+func (r *SampleReconciler) getResourcesFromSource(ctx context.Context,
+	objectInstance *v1alpha1.Sample,
+) (*ManifestResources, error) {
+	source := objectInstance.Spec.Source
+	if configured := configuredSources(source); len(configured) > 1 {
+		return nil, fmt.Errorf("%w: spec.source configures multiple manifest sources %v, set only one of them",
+			errManifestAmbiguous, configured)
+	}
+
+	switch {
+	case len(source.KeyRefs) > 0:
+		return r.getResourcesFromKeyRefs(ctx, objectInstance)
+	case source.URL != nil:
+		return r.getResourcesFromURL(ctx, objectInstance)
+	case source.OCI != nil:
+		return r.getResourcesFromOCILayout(objectInstance)
+	case source.Git != nil:
+		return r.getResourcesFromGit(ctx, objectInstance)
+	default:
+		return nil, fmt.Errorf("%w: spec.source configures no manifest source", errManifestNotFound)
+	}
+}
+
+// configuredSources returns the names of all manifest sources set in source.
+func configuredSources(source *v1alpha1.ManifestSource) []string {
+	configured := make([]string, 0, 1)
+	if len(source.KeyRefs) > 0 {
+		configured = append(configured, "keyRefs")
+	}
+	if source.URL != nil {
+		configured = append(configured, "url")
+	}
+	if source.OCI != nil {
+		configured = append(configured, "oci")
+	}
+	if source.Git != nil {
+		configured = append(configured, "git")
+	}
+	return configured
+}
+
+// getResourcesFromLocalSource returns the resources of the local ResourceFilePath.
+// The ResourceFilePath is watched, so changes of its files are reconciled without waiting for the next resync.
+func (r *SampleReconciler) getResourcesFromLocalSource(ctx context.Context,
+	objectInstance *v1alpha1.Sample,
+) (*ManifestResources, error) {
+	resourceObjs, err := r.loadLocalManifest(ctx, objectInstance, objectInstance.Spec.ResourceFilePath,
+		r.manifestFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.manifestWatcher.watch(objectInstance.Spec.ResourceFilePath); err != nil {
+		// changes of the files are still reconciled with the next resync
+		log.FromContext(ctx).Error(err, "error watching manifest files")
+	}
+	return resourceObjs, nil
+}
+
+// loadLocalManifest returns the resources of the manifest at the local resourcePath.
+// If the resourcePath points to a kustomization, it is built in-process.
+// If the resourcePath points to a Helm chart, the chart is rendered with the release name and values
+// of the reconciled resource, otherwise the manifest is read from the resourcePath as is.
+// Manifest files are served from the files cache, a nil cache reads and parses them with every load.
+func (r *SampleReconciler) loadLocalManifest(ctx context.Context,
+	objectInstance *v1alpha1.Sample, resourcePath string, files *manifestFileCache,
+) (*ManifestResources, error) {
+	srcType, err := resolveLocalSource(resourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var resourceObjs *ManifestResources
+	switch srcType {
+	case sourceTypeFile:
+		resourceObjs, err = files.load(resourcePath)
+	case sourceTypeDirectory:
+		resourceObjs, err = getResourcesFromLocalPath(resourcePath, loadOptions{
+			recursive: objectInstance.Spec.Recursive,
+			include:   objectInstance.Spec.Include,
+			exclude:   objectInstance.Spec.Exclude,
+			indexFile: objectInstance.Spec.IndexFile,
+		}, files, log.FromContext(ctx))
+	case sourceTypeKustomization:
+		resourceObjs, err = buildKustomization(resourcePath)
+	case sourceTypeHelmChart:
+		resourceObjs, err = r.renderHelmRelease(ctx, objectInstance, resourcePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resourceObjs.Source = v1alpha1.SourceStatus{Type: string(srcType)}
+	return resourceObjs, nil
+}
+
+// renderHelmRelease renders the Helm chart at chartPath with the release name and values of the reconciled resource.
+func (r *SampleReconciler) renderHelmRelease(ctx context.Context,
+	objectInstance *v1alpha1.Sample, chartPath string,
+) (*ManifestResources, error) {
+	values, err := r.getHelmValues(ctx, objectInstance)
+	if err != nil {
+		return nil, err
+	}
+	releaseName := objectInstance.Spec.ReleaseName
+	if releaseName == "" {
+		releaseName = objectInstance.GetName()
+	}
+	resourceObjs, err := renderHelmChart(chartPath, renderOptions{
+		releaseName: releaseName,
+		namespace:   objectInstance.GetNamespace(),
+		values:      values,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resourceObjs, r.setDefaultNamespace(resourceObjs, objectInstance.GetNamespace())
+}
+
+// validateManifestResources ensures that the resolved manifest contains at least one resource.
+// The location describes where the manifest was loaded from.
+func validateManifestResources(resourceObjs *ManifestResources, location string) error {
+	switch {
+	case resourceObjs != nil && len(resourceObjs.Items) > 0:
+		return nil
+	case resourceObjs != nil && len(resourceObjs.Blobs) > 0:
+		return fmt.Errorf("%w: none of the %d documents at %s is a valid resource",
+			errManifestParse, len(resourceObjs.Blobs), location)
+	default:
+		return fmt.Errorf("%w: manifest at %s contains no resources", errManifestNotFound, location)
+	}
+}
+
+// getSkippedDocuments returns the documents of the manifest which are skipped in Lenient parse mode,
+// limited to v1alpha1.MaxSkippedDocuments. In Strict parse mode, an error pointing to the first document
+// which could not be parsed is returned instead.
+func getSkippedDocuments(blobs []ManifestBlob, parseMode v1alpha1.ParseMode) ([]v1alpha1.SkippedDocument, error) {
+	if len(blobs) == 0 {
+		return nil, nil
+	}
+	if parseMode != v1alpha1.ParseModeLenient {
+		blob := blobs[0]
+		return nil, fmt.Errorf("%w: document %d of %s starting at line %d: %w, "+
+			"fix the document or set spec.parseMode to %s to skip it",
+			errManifestParse, blob.Index, blob.Source, blob.Line, blob.Err, v1alpha1.ParseModeLenient)
+	}
+
+	skippedDocuments := make([]v1alpha1.SkippedDocument, 0, min(len(blobs), v1alpha1.MaxSkippedDocuments))
+	for _, blob := range blobs[:min(len(blobs), v1alpha1.MaxSkippedDocuments)] {
+		skippedDocuments = append(skippedDocuments, v1alpha1.SkippedDocument{
+			Source:  blob.Source,
+			Index:   blob.Index,
+			Line:    blob.Line,
+			Message: blob.Err.Error(),
+		})
+	}
+	return skippedDocuments, nil
+}
+
+// setDefaultNamespace sets the passed namespace on all namespaced resources without a namespace,
+// the same way Helm installs a release into its namespace.
+func (r *SampleReconciler) setDefaultNamespace(resourceObjs *ManifestResources, namespace string) error {
+	for _, obj := range resourceObjs.Items {
+		if obj.GetNamespace() != "" {
+			continue
+		}
+		namespaced, err := r.IsObjectNamespaced(obj)
+		if meta.IsNoMatchError(err) {
+			// the type might be introduced by the manifest itself, it is defaulted once the type is available
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("scope of resource %s could not be determined: %w", obj.GetName(), err)
+		}
+		if namespaced {
+			obj.SetNamespace(namespace)
+		}
+	}
+	return nil
+}
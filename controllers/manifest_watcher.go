@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// manifestWatcher watches the local resource paths of Samples for changes of their files,
+// like updates of a ConfigMap mounted as volume, so Samples are reconciled without waiting for the next resync.
+// Directories are watched, as files of mounted volumes are replaced by swapping a symbolic link of their directory.
+type manifestWatcher struct {
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	// dirs are the watched directories of each watched resource path
+	dirs map[string]sets.Set[string]
+}
+
+func newManifestWatcher() (*manifestWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher of manifest files: %w", err)
+	}
+	return &manifestWatcher{watcher: watcher, dirs: make(map[string]sets.Set[string])}, nil
+}
+
+// watch watches the directory of the file at resourcePath, or the directory at resourcePath
+// together with all directories below it.
+func (w *manifestWatcher) watch(resourcePath string) error {
+	if w == nil {
+		return nil
+	}
+	resourcePath = filepath.Clean(resourcePath)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, found := w.dirs[resourcePath]; found {
+		return nil
+	}
+
+	info, err := os.Stat(resourcePath)
+	if err != nil {
+		return fmt.Errorf("resource path %s could not be watched: %w", resourcePath, err)
+	}
+	dirs := sets.New(filepath.Dir(resourcePath))
+	if info.IsDir() {
+		if dirs, err = subDirsOf(resourcePath); err != nil {
+			return fmt.Errorf("resource path %s could not be watched: %w", resourcePath, err)
+		}
+	}
+	if err = w.addDirs(resourcePath, dirs); err != nil {
+		w.unwatchLocked(resourcePath)
+		return fmt.Errorf("resource path %s could not be watched: %w", resourcePath, err)
+	}
+	return nil
+}
+
+// unwatch removes the watches of resourcePath, except for directories watched for other resource paths.
+func (w *manifestWatcher) unwatch(resourcePath string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.unwatchLocked(filepath.Clean(resourcePath))
+}
+
+func (w *manifestWatcher) unwatchLocked(resourcePath string) {
+	dirs := w.dirs[resourcePath]
+	delete(w.dirs, resourcePath)
+	for dir := range dirs {
+		if !w.isWatched(dir) {
+			// watches of removed directories are already removed
+			_ = w.watcher.Remove(dir)
+		}
+	}
+}
+
+// addDirs watches dirs for resourcePath.
+func (w *manifestWatcher) addDirs(resourcePath string, dirs sets.Set[string]) error {
+	if _, found := w.dirs[resourcePath]; !found {
+		w.dirs[resourcePath] = sets.New[string]()
+	}
+	for dir := range dirs {
+		if !w.isWatched(dir) {
+			if err := w.watcher.Add(dir); err != nil {
+				return err
+			}
+		}
+		w.dirs[resourcePath].Insert(dir)
+	}
+	return nil
+}
+
+func (w *manifestWatcher) isWatched(dir string) bool {
+	for _, dirs := range w.dirs {
+		if dirs.Has(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// subDirsOf returns dirPath and all directories below it.
+func subDirsOf(dirPath string) (sets.Set[string], error) {
+	dirs := sets.New[string]()
+	err := filepath.WalkDir(dirPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			dirs.Insert(filePath)
+		}
+		return err
+	})
+	return dirs, err
+}
+
+// startManifestWatcher enqueues the Samples whose resource path contains a changed file, until ctx is done.
+// It implements a source.Func, so it is started together with the controller.
+func (r *SampleReconciler) startManifestWatcher(ctx context.Context,
+	queue workqueue.TypedRateLimitingInterface[reconcile.Request],
+) error {
+	w := r.manifestWatcher
+	go func() {
+		logger := log.FromContext(ctx).WithName("manifest-watcher")
+		defer w.watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.watcher.Events:
+				if !ok {
+					return
+				}
+				w.handle(event)
+				for _, req := range r.requestsForManifestPath(ctx, filepath.Dir(event.Name)) {
+					queue.Add(req)
+				}
+			case err, ok := <-w.watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Error(err, "error watching manifest files")
+			}
+		}
+	}()
+	return nil
+}
+
+// handle watches directories created below a watched directory, and removes the watches of removed
+// resource paths, so they are watched again once they are recreated.
+func (w *manifestWatcher) handle(event fsnotify.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		w.unwatchLocked(event.Name)
+		for _, dirs := range w.dirs {
+			for dir := range dirs {
+				if isPathWithin(dir, event.Name) {
+					_ = w.watcher.Remove(dir)
+					dirs.Delete(dir)
+				}
+			}
+		}
+		return
+	}
+	if !event.Has(fsnotify.Create) {
+		return
+	}
+	info, err := os.Lstat(event.Name)
+	if err != nil || !info.IsDir() {
+		return
+	}
+	dirs, err := subDirsOf(event.Name)
+	if err != nil {
+		return
+	}
+	for resourcePath := range w.dirs {
+		if isPathWithin(event.Name, resourcePath) {
+			_ = w.addDirs(resourcePath, dirs)
+		}
+	}
+}
+
+// requestsForManifestPath returns requests for all Samples whose local resource path is the changed dirPath,
+// contains it, or is a file in it.
+func (r *SampleReconciler) requestsForManifestPath(ctx context.Context, dirPath string) []reconcile.Request {
+	samples := &v1alpha1.SampleList{}
+	if err := r.List(ctx, samples); err != nil {
+		log.FromContext(ctx).Error(err, "error listing samples for changed manifest files", "path", dirPath)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0)
+	for _, sample := range samples.Items {
+		if sample.Spec.Source != nil || sample.Spec.ResourceFilePath == "" {
+			continue
+		}
+		resourcePath := filepath.Clean(sample.Spec.ResourceFilePath)
+		if isPathWithin(dirPath, resourcePath) || filepath.Dir(resourcePath) == dirPath {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: sample.GetNamespace(), Name: sample.GetName()},
+			})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	applyResultPerformed = "performed"
+	applyResultSkipped   = "skipped"
+)
+
+// resourceApplies counts the resources which were applied, or whose apply was skipped
+// as neither the manifest nor the applied resources changed, see installationChangeOf.
+var resourceApplies = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "template_operator_resource_applies_total",
+	Help: "Number of resources applied or skipped, as neither the manifest nor the resources changed",
+}, []string{"result"})
+
+func init() { //nolint:gochecknoinits
+	metrics.Registry.MustRegister(resourceApplies)
+}
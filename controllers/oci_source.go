@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// getResourcesFromOCILayout returns the resources of the image selected in spec.source.oci in unstructured format.
+// The digests of the image manifest and of all layers are verified before the manifest is extracted.
+func (r *SampleReconciler) getResourcesFromOCILayout(objectInstance *v1alpha1.Sample) (*ManifestResources, error) {
+	ociSource := objectInstance.Spec.Source.OCI
+	layoutPath := ociSource.LayoutPath
+	if err := checkOCILayout(layoutPath); err != nil {
+		return nil, err
+	}
+
+	descriptor, err := resolveOCIImage(layoutPath, ociSource)
+	if err != nil {
+		return nil, err
+	}
+	manifestContent, err := readOCIBlob(layoutPath, descriptor)
+	if err != nil {
+		return nil, err
+	}
+	imageManifest := ocispec.Manifest{}
+	if err = json.Unmarshal(manifestContent, &imageManifest); err != nil {
+		return nil, fmt.Errorf("%w: image manifest %s could not be parsed: %w",
+			errManifestParse, descriptor.Digest, err)
+	}
+
+	resources := &ManifestResources{}
+	supportedLayers := 0
+	for _, layer := range imageManifest.Layers {
+		layerResources, supported, err := readOCILayer(layoutPath, layer)
+		if err != nil {
+			return nil, err
+		}
+		if supported {
+			supportedLayers++
+			resources.merge(layerResources)
+		}
+	}
+	if supportedLayers == 0 {
+		return nil, fmt.Errorf("%w: image %s has no layer with a YAML or tar media type",
+			errManifestUnsupportedFormat, descriptor.Digest)
+	}
+
+	resources.Source = v1alpha1.SourceStatus{Type: string(sourceTypeOCI), Digest: descriptor.Digest.String()}
+	return resources, r.setDefaultNamespace(resources, objectInstance.GetNamespace())
+}
+
+// checkOCILayout ensures that layoutPath is an OCI image layout directory.
+func checkOCILayout(layoutPath string) error {
+	content, err := os.ReadFile(filepath.Join(layoutPath, ocispec.ImageLayoutFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s is not an OCI image layout, check spec.source.oci.layoutPath",
+			errManifestNotFound, layoutPath)
+	}
+	if err != nil {
+		return fmt.Errorf("OCI image layout %s could not be read: %w", layoutPath, err)
+	}
+
+	layout := ocispec.ImageLayout{}
+	if err = json.Unmarshal(content, &layout); err != nil || layout.Version != ocispec.ImageLayoutVersion {
+		return fmt.Errorf("%w: %s is not an OCI image layout of version %s",
+			errManifestUnsupportedFormat, layoutPath, ocispec.ImageLayoutVersion)
+	}
+	return nil
+}
+
+// resolveOCIImage returns the descriptor of the image manifest in the index of the layout
+// matching the tag or digest of ociSource.
+func resolveOCIImage(layoutPath string, ociSource *v1alpha1.OCISource) (ocispec.Descriptor, error) {
+	content, err := os.ReadFile(filepath.Join(layoutPath, ocispec.ImageIndexFile))
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("%w: index of OCI image layout %s could not be read: %w",
+			errManifestNotFound, layoutPath, err)
+	}
+	index := ocispec.Index{}
+	if err = json.Unmarshal(content, &index); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("%w: index of OCI image layout %s could not be parsed: %w",
+			errManifestParse, layoutPath, err)
+	}
+
+	for _, descriptor := range index.Manifests {
+		matches := (ociSource.Tag != "" && descriptor.Annotations[ocispec.AnnotationRefName] == ociSource.Tag) ||
+			(ociSource.Digest != "" && descriptor.Digest.String() == ociSource.Digest)
+		if !matches {
+			continue
+		}
+		if descriptor.MediaType != ocispec.MediaTypeImageManifest {
+			return ocispec.Descriptor{}, fmt.Errorf("%w: %s references a %s, expected an image manifest",
+				errManifestUnsupportedFormat, ociReference(ociSource), descriptor.MediaType)
+		}
+		return descriptor, nil
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("%w: image %s not found in the index of OCI image layout %s",
+		errManifestNotFound, ociReference(ociSource), layoutPath)
+}
+
+func ociReference(ociSource *v1alpha1.OCISource) string {
+	if ociSource.Digest != "" {
+		return ociSource.Digest
+	}
+	return ociSource.Tag
+}
+
+// readOCILayer returns the resources contained in the layer described by descriptor,
+// and whether the media type of the layer is supported at all.
+func readOCILayer(layoutPath string, descriptor ocispec.Descriptor) (*ManifestResources, bool, error) {
+	var tarLayer bool
+	switch mediaType := descriptor.MediaType; {
+	case mediaType == ocispec.MediaTypeImageLayer || mediaType == ocispec.MediaTypeImageLayerGzip:
+		tarLayer = true
+	case strings.HasSuffix(mediaType, "yaml"):
+		tarLayer = false
+	default:
+		return nil, false, nil
+	}
+
+	content, err := readOCIBlob(layoutPath, descriptor)
+	if err != nil {
+		return nil, true, err
+	}
+	content, err = decompressManifest(content)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: layer %s could not be decompressed: %w",
+			errManifestParse, descriptor.Digest, err)
+	}
+
+	if !tarLayer {
+		return parseManifestStringToObjects(descriptor.Digest.String(), string(content)), true, nil
+	}
+	resources, err := extractTarManifests(descriptor.Digest.String(), content)
+	return resources, true, err
+}
+
+// readOCIBlob reads the blob described by descriptor from the layout and verifies its size and digest.
+func readOCIBlob(layoutPath string, descriptor ocispec.Descriptor) ([]byte, error) {
+	if err := descriptor.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: digest %s is invalid: %w", errManifestParse, descriptor.Digest, err)
+	}
+	if descriptor.Size > maxManifestSize {
+		return nil, fmt.Errorf("blob %s exceeds %d bytes", descriptor.Digest, maxManifestSize)
+	}
+
+	blobPath := filepath.Join(layoutPath, ocispec.ImageBlobsDir,
+		descriptor.Digest.Algorithm().String(), descriptor.Digest.Encoded())
+	content, err := os.ReadFile(blobPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: blob %s is missing in OCI image layout %s",
+			errManifestNotFound, descriptor.Digest, layoutPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("blob %s could not be read: %w", descriptor.Digest, err)
+	}
+
+	if actual := descriptor.Digest.Algorithm().FromBytes(content); actual != descriptor.Digest ||
+		int64(len(content)) != descriptor.Size {
+		return nil, fmt.Errorf("%w: blob %s has digest %s and size %d, expected size %d",
+			errManifestDigestMismatch, descriptor.Digest, actual, len(content), descriptor.Size)
+	}
+	return content, nil
+}
+
+// extractTarManifests returns the resources of all .yaml, .yml and .json files of the tar archive,
+// merged in lexical order of their paths.
+func extractTarManifests(source string, content []byte) (*ManifestResources, error) {
+	manifests := map[string]string{}
+	reader := tar.NewReader(bytes.NewReader(content))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: layer %s is not a valid tar archive: %w", errManifestParse, source, err)
+		}
+		if header.Typeflag != tar.TypeReg || !manifestFileExtensions.Has(path.Ext(header.Name)) {
+			continue
+		}
+		// sparse files can expand beyond the size of the archive, so the size of each file is limited as well
+		data, err := io.ReadAll(io.LimitReader(reader, maxManifestSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: file %s of layer %s could not be read: %w",
+				errManifestParse, header.Name, source, err)
+		}
+		if len(data) > maxManifestSize {
+			return nil, fmt.Errorf("%w: file %s of layer %s exceeds %d bytes",
+				errManifestParse, header.Name, source, maxManifestSize)
+		}
+		manifests[header.Name] = string(data)
+	}
+
+	names := make([]string, 0, len(manifests))
+	for name := range manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resources := &ManifestResources{}
+	for _, name := range names {
+		resources.merge(parseManifestStringToObjects(source+"/"+name, manifests[name]))
+	}
+	return resources, nil
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// applyGroupsOf groups objs sorted by sortByApplyOrder into groups of the same apply order.
+// Resources of a group do not depend on each other, so they can be applied in parallel.
+func applyGroupsOf(objs []*unstructured.Unstructured) [][]*unstructured.Unstructured {
+	groups := make([][]*unstructured.Unstructured, 0)
+	for i, obj := range objs {
+		if i == 0 || applyOrderOf(objs[i-1]) != applyOrderOf(obj) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], obj)
+	}
+	return groups
+}
+
+// applyInParallel applies objs with at most MaxConcurrentApplies resources at a time. All resources are applied,
+// even if some of them fail, and the error of each resource is returned at the position of the resource in objs.
+func (r *SampleReconciler) applyInParallel(ctx context.Context, objectInstance *v1alpha1.Sample,
+	objs []*unstructured.Unstructured,
+) []error {
+	errs := make([]error, len(objs))
+	group := errgroup.Group{}
+	group.SetLimit(max(r.MaxConcurrentApplies, 1))
+	for i, obj := range objs {
+		group.Go(func() error {
+			errs[i] = r.apply(ctx, objectInstance, obj)
+			return nil
+		})
+	}
+	_ = group.Wait()
+	return errs
+}
+
+// apply applies obj labeled with the reconciled resource.
+func (r *SampleReconciler) apply(ctx context.Context, objectInstance *v1alpha1.Sample,
+	obj *unstructured.Unstructured,
+) error {
+	setSampleLabels(obj, objectInstance)
+	resourceApplies.WithLabelValues(applyResultPerformed).Inc()
+	err := r.ssa(ctx, obj)
+	if errors2.IsAlreadyExists(err) {
+		err = nil
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// maxListedNotReadyResources limits the resources named in the Installation condition while waiting for readiness.
+const maxListedNotReadyResources = 3
+
+// setReadinessStatus sets the state of the reconciled resource after its resources were applied successfully,
+// see withReadinessStatus. The status is only updated if it changed, so polling for readiness does not cause writes.
+func (r *SampleReconciler) setReadinessStatus(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus,
+) error {
+	previous := meta.FindStatusCondition(objectInstance.Status.Conditions, v1alpha1.ConditionTypeInstallation)
+	r.withReadinessStatus(status, objectInstance.GetGeneration())
+	if equality.Semantic.DeepEqual(*status, objectInstance.Status) {
+		return nil
+	}
+
+	condition := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionTypeInstallation)
+	unhealthy := condition.Reason == v1alpha1.ConditionReasonReadinessTimeout ||
+		condition.Reason == v1alpha1.ConditionReasonResourcesDegraded
+	if unhealthy && (previous == nil || previous.Reason != condition.Reason) {
+		r.Event(objectInstance, "Warning", condition.Reason, condition.Message)
+	}
+	return r.setStatusForObjectInstance(ctx, objectInstance, status)
+}
+
+// withReadinessStatus sets the state and Installation condition of a resource whose resources were applied.
+// Once all resources are healthy, the installation is complete, see withInstalledStatus.
+// If resources are degraded, or resources of a complete installation are not healthy anymore,
+// the state is Warning until they are healthy again. Otherwise, the state is Processing while
+// waiting for the resources to become ready, or ReadinessTimeoutState once they did not become ready
+// within ReadinessTimeout since the installation started. Resources with an unknown health are not waited for.
+// While hooks are running, the state is Processing.
+func (r *SampleReconciler) withReadinessStatus(status *v1alpha1.SampleStatus,
+	objGeneration int64,
+) *v1alpha1.SampleStatus {
+	if running := runningHooks(status, objGeneration); running != "" {
+		return status.
+			WithState(v1alpha1.StateProcessing).
+			WithInstallConditionStatus(metav1.ConditionUnknown, objGeneration).
+			WithInstallConditionReason(v1alpha1.ConditionReasonHooksRunning, running)
+	}
+
+	notReady := describeResources(status.Resources, v1alpha1.ResourceHealthProgressing, v1alpha1.ResourceHealthDegraded)
+	if notReady == "" {
+		return r.withInstalledStatus(status, objGeneration)
+	}
+
+	condition := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionTypeInstallation)
+	installed := condition != nil && condition.Status == metav1.ConditionTrue &&
+		condition.ObservedGeneration == objGeneration
+	if degraded := describeResources(status.Resources, v1alpha1.ResourceHealthDegraded); degraded != "" || installed {
+		return status.
+			WithState(v1alpha1.StateWarning).
+			WithInstallConditionStatus(metav1.ConditionTrue, objGeneration).
+			WithInstallConditionReason(v1alpha1.ConditionReasonResourcesDegraded,
+				"resources are installed, but not healthy: "+notReady)
+	}
+
+	timedOut := isReadinessTimeout(status, objGeneration) ||
+		(r.ReadinessTimeout > 0 && condition != nil && condition.Status == metav1.ConditionUnknown &&
+			time.Since(condition.LastTransitionTime.Time) > r.ReadinessTimeout)
+	if timedOut {
+		return status.
+			WithState(r.ReadinessTimeoutState).
+			WithInstallConditionStatus(metav1.ConditionFalse, objGeneration).
+			WithInstallConditionReason(v1alpha1.ConditionReasonReadinessTimeout,
+				fmt.Sprintf("resources did not become ready within %s: %s", r.ReadinessTimeout, notReady))
+	}
+	return status.
+		WithState(v1alpha1.StateProcessing).
+		WithInstallConditionStatus(metav1.ConditionUnknown, objGeneration).
+		WithInstallConditionReason(v1alpha1.ConditionReasonResourcesNotReady,
+			"waiting for resources to become ready: "+notReady)
+}
+
+// isReadinessTimeout reports whether the resources of the current generation already did not become ready in time.
+// The timeout only ends with the readiness of the resources or a new generation of the reconciled resource.
+func isReadinessTimeout(status *v1alpha1.SampleStatus, objGeneration int64) bool {
+	condition := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionTypeInstallation)
+	return condition != nil && condition.Reason == v1alpha1.ConditionReasonReadinessTimeout &&
+		condition.ObservedGeneration == objGeneration
+}
+
+// describeResources describes the resources with one of the given health values with their message,
+// or returns an empty string if there are none.
+func describeResources(resources []v1alpha1.ResourceStatus, health ...v1alpha1.ResourceHealth) string {
+	names := make([]string, 0, maxListedNotReadyResources)
+	matching := 0
+	for _, resource := range resources {
+		if !slices.Contains(health, resource.Health) {
+			continue
+		}
+		matching++
+		if len(names) < maxListedNotReadyResources {
+			names = append(names, fmt.Sprintf("%s (%s)", resource.InventoryEntry, resource.Message))
+		}
+	}
+	if matching > len(names) {
+		names = append(names, fmt.Sprintf("and %d more", matching-len(names)))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+const (
+	// referencedObjectsIndex indexes Samples by the ConfigMaps and Secrets they reference.
+	referencedObjectsIndex = "spec.referencedObjects"
+	// maxManifestSize limits the size of fetched and decompressed manifests to protect against oversized content.
+	maxManifestSize = 64 << 20
+)
+
+//nolint:gochecknoglobals
+var gzipMagicBytes = []byte{0x1f, 0x8b}
+
+// getResourcesFromKeyRefs returns the resources of the manifests referenced in spec.source.keyRefs
+// in unstructured format, merged in the order of declaration.
+// Namespaced resources without a namespace are installed into the namespace of the reconciled resource.
+func (r *SampleReconciler) getResourcesFromKeyRefs(ctx context.Context,
+	objectInstance *v1alpha1.Sample,
+) (*ManifestResources, error) {
+	resources := &ManifestResources{Source: v1alpha1.SourceStatus{Type: string(sourceTypeKeyRefs)}}
+	for _, ref := range objectInstance.Spec.Source.KeyRefs {
+		data, resourceVersion, found, err := r.getReferencedData(ctx, objectInstance.GetNamespace(),
+			ref.Kind, ref.Name, ref.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: key %s of %s %s/%s not found, check spec.source.keyRefs",
+				errManifestNotFound, ref.Key, ref.Kind, objectInstance.GetNamespace(), ref.Name)
+		}
+
+		source := fmt.Sprintf("%s %s key %s", ref.Kind, ref.Name, ref.Key)
+		manifest, err := decompressManifest(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s could not be decompressed: %w", errManifestParse, source, err)
+		}
+		resources.merge(parseManifestStringToObjects(source, string(manifest)))
+		resources.Source.ReferencedObjects = append(resources.Source.ReferencedObjects, v1alpha1.ReferencedObject{
+			Kind:            ref.Kind,
+			Name:            ref.Name,
+			ResourceVersion: resourceVersion,
+		})
+	}
+	return resources, r.setDefaultNamespace(resources, objectInstance.GetNamespace())
+}
+
+// getReferencedData returns the value of key in the data or binaryData of the referenced ConfigMap or Secret,
+// together with the resourceVersion of the referenced resource. A missing resource or key is reported as not found.
+func (r *SampleReconciler) getReferencedData(ctx context.Context, namespace string, kind v1alpha1.ReferenceKind,
+	name, key string,
+) ([]byte, string, bool, error) {
+	objKey := client.ObjectKey{Namespace: namespace, Name: name}
+	switch kind {
+	case v1alpha1.ReferenceKindConfigMap:
+		configMap := &corev1.ConfigMap{}
+		if err := r.Get(ctx, objKey, configMap); err != nil {
+			return nil, "", false, wrapReferenceError(kind, objKey, err)
+		}
+		if value, found := configMap.Data[key]; found {
+			return []byte(value), configMap.GetResourceVersion(), true, nil
+		}
+		value, found := configMap.BinaryData[key]
+		return value, configMap.GetResourceVersion(), found, nil
+	case v1alpha1.ReferenceKindSecret:
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, objKey, secret); err != nil {
+			return nil, "", false, wrapReferenceError(kind, objKey, err)
+		}
+		value, found := secret.Data[key]
+		return value, secret.GetResourceVersion(), found, nil
+	default:
+		return nil, "", false, fmt.Errorf("reference kind %s is not supported", kind)
+	}
+}
+
+func wrapReferenceError(kind v1alpha1.ReferenceKind, objKey client.ObjectKey, err error) error {
+	if client.IgnoreNotFound(err) == nil {
+		return nil
+	}
+	return fmt.Errorf("%s %s could not be read: %w", kind, objKey, err)
+}
+
+// decompressManifest returns the passed data decompressed if it is gzip compressed, otherwise as is.
+func decompressManifest(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagicBytes) {
+		return data, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > maxManifestSize {
+		return nil, fmt.Errorf("decompressed manifest exceeds %d bytes", maxManifestSize)
+	}
+	return decompressed, nil
+}
+
+// indexReferencedObjects returns the index values of all ConfigMaps and Secrets referenced by a Sample.
+func indexReferencedObjects(obj client.Object) []string {
+	sample, ok := obj.(*v1alpha1.Sample)
+	if !ok {
+		return nil
+	}
+
+	indexValues := make([]string, 0)
+	for _, ref := range sample.Spec.ValuesFrom {
+		indexValues = append(indexValues, referencedObjectIndexValue(ref.Kind, ref.Name))
+	}
+	if sample.Spec.Source != nil {
+		for _, ref := range sample.Spec.Source.KeyRefs {
+			indexValues = append(indexValues, referencedObjectIndexValue(ref.Kind, ref.Name))
+		}
+		if sample.Spec.Source.URL != nil && sample.Spec.Source.URL.AuthSecretName != "" {
+			indexValues = append(indexValues,
+				referencedObjectIndexValue(v1alpha1.ReferenceKindSecret, sample.Spec.Source.URL.AuthSecretName))
+		}
+	}
+	return indexValues
+}
+
+func referencedObjectIndexValue(kind v1alpha1.ReferenceKind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+// requestsForReferencedObject maps a changed ConfigMap or Secret to requests for all Samples referencing it.
+func (r *SampleReconciler) requestsForReferencedObject(kind v1alpha1.ReferenceKind,
+) func(ctx context.Context, obj client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		samples := &v1alpha1.SampleList{}
+		if err := r.List(ctx, samples, client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{referencedObjectsIndex: referencedObjectIndexValue(kind, obj.GetName())},
+		); err != nil {
+			log.FromContext(ctx).Error(err, "error listing samples referencing "+obj.GetName())
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(samples.Items))
+		for i := range samples.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&samples.Items[i])})
+		}
+		return requests
+	}
+}
@@ -0,0 +1,372 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+const (
+	// maxResourceMessageLength limits the message of a single resource in the status of a Sample.
+	maxResourceMessageLength = 256
+	// unavailableReplicasGracePeriod is the time replicas of a Deployment may be unavailable, before the
+	// Deployment needs user action, like a missing image or a failing readiness probe, and is degraded.
+	unavailableReplicasGracePeriod = 2 * time.Minute
+	// pendingClaimGracePeriod is the time a PersistentVolumeClaim may be pending, before the claim needs
+	// user action, like a missing storage class or volume, and is degraded.
+	pendingClaimGracePeriod = 2 * time.Minute
+	// selectedNodeAnnotation is set on a claim of a WaitForFirstConsumer storage class once its first consumer
+	// is scheduled, see isWaitingForFirstConsumer.
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+)
+
+//nolint:gochecknoglobals
+var healthSeverity = map[v1alpha1.ResourceHealth]int{
+	v1alpha1.ResourceHealthHealthy:     0,
+	v1alpha1.ResourceHealthUnknown:     1,
+	v1alpha1.ResourceHealthProgressing: 2,
+	v1alpha1.ResourceHealthDegraded:    3,
+}
+
+// healthEvaluator determines the health of an applied resource of a well-known kind from its status.
+type healthEvaluator func(obj *unstructured.Unstructured) (v1alpha1.ResourceHealth, string)
+
+//nolint:gochecknoglobals
+var healthEvaluators = map[schema.GroupKind]healthEvaluator{
+	{Group: "apps", Kind: "Deployment"}:                               typedHealth(deploymentHealth),
+	{Group: "apps", Kind: "StatefulSet"}:                              typedHealth(statefulSetHealth),
+	{Group: "apps", Kind: "DaemonSet"}:                                typedHealth(daemonSetHealth),
+	{Group: "batch", Kind: "Job"}:                                     typedHealth(jobHealth),
+	{Group: "", Kind: "Pod"}:                                          typedHealth(podHealth),
+	{Group: "", Kind: "PersistentVolumeClaim"}:                        typedHealth(persistentVolumeClaimHealth),
+	{Group: "", Kind: "Service"}:                                      typedHealth(serviceHealth),
+	{Group: "", Kind: "Namespace"}:                                    typedHealth(namespaceHealth),
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: customResourceDefinitionHealth,
+}
+
+// resourceHealth determines the health of an applied resource. Resources of well-known kinds are evaluated
+// by their healthEvaluator, all other resources by the conditions in their status.
+func resourceHealth(obj *unstructured.Unstructured) (v1alpha1.ResourceHealth, string) {
+	if evaluate, found := healthEvaluators[obj.GroupVersionKind().GroupKind()]; found {
+		return evaluate(obj)
+	}
+	return conditionsHealth(obj)
+}
+
+// conditionsHealth determines the health of an applied resource from the conditions in its status.
+// Resources without a status are healthy once applied, resources with a status but without
+// any well-known condition are reported with an unknown health.
+func conditionsHealth(obj *unstructured.Unstructured) (v1alpha1.ResourceHealth, string) {
+	status, found, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil || !found || len(status) == 0 {
+		return v1alpha1.ResourceHealthHealthy, ""
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(status, "conditions")
+	for _, conditionType := range []string{"Failed", "Degraded"} {
+		if condition := findCondition(conditions, conditionType); condition != nil && condition.status == "True" {
+			return v1alpha1.ResourceHealthDegraded, condition.describe()
+		}
+	}
+	for _, conditionType := range []string{"Ready", "Available"} {
+		condition := findCondition(conditions, conditionType)
+		switch {
+		case condition == nil:
+			continue
+		case condition.status == "True":
+			return v1alpha1.ResourceHealthHealthy, ""
+		default:
+			return v1alpha1.ResourceHealthProgressing, condition.describe()
+		}
+	}
+	return v1alpha1.ResourceHealthUnknown, "no Ready or Available condition found in status"
+}
+
+// typedHealth converts an unstructured resource to its typed representation before evaluating its health.
+func typedHealth[T any](evaluate func(obj *T) (v1alpha1.ResourceHealth, string)) healthEvaluator {
+	return func(obj *unstructured.Unstructured) (v1alpha1.ResourceHealth, string) {
+		typed := new(T)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+			return v1alpha1.ResourceHealthUnknown, fmt.Sprintf("status could not be read: %v", err)
+		}
+		return evaluate(typed)
+	}
+}
+
+func deploymentHealth(deployment *appsv1.Deployment) (v1alpha1.ResourceHealth, string) {
+	if deployment.Status.ObservedGeneration < deployment.GetGeneration() {
+		return v1alpha1.ResourceHealthProgressing, "waiting for the rollout to be observed"
+	}
+	var progressing, available *appsv1.DeploymentCondition
+	for i, condition := range deployment.Status.Conditions {
+		switch condition.Type {
+		case appsv1.DeploymentProgressing:
+			progressing = &deployment.Status.Conditions[i]
+		case appsv1.DeploymentAvailable:
+			available = &deployment.Status.Conditions[i]
+		}
+	}
+	if progressing != nil && progressing.Reason == "ProgressDeadlineExceeded" {
+		return v1alpha1.ResourceHealthDegraded, progressing.Message
+	}
+	// after a complete rollout, more replicas than allowed by maxUnavailable became unavailable
+	if progressing != nil && progressing.Reason == "NewReplicaSetAvailable" &&
+		available != nil && available.Status == corev1.ConditionFalse {
+		return v1alpha1.ResourceHealthDegraded, available.Message
+	}
+
+	replicas := ptr.Deref(deployment.Spec.Replicas, 1)
+	switch status := deployment.Status; {
+	case status.UpdatedReplicas < replicas:
+		return v1alpha1.ResourceHealthProgressing,
+			fmt.Sprintf("%d of %d replicas are updated", status.UpdatedReplicas, replicas)
+	case status.Replicas > status.UpdatedReplicas:
+		return v1alpha1.ResourceHealthProgressing,
+			fmt.Sprintf("%d old replicas are pending termination", status.Replicas-status.UpdatedReplicas)
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		message := fmt.Sprintf("%d of %d updated replicas are available", status.AvailableReplicas, status.UpdatedReplicas)
+		// also during the first rollout, replicas which do not become available need user action
+		if available != nil && available.Status == corev1.ConditionFalse &&
+			time.Since(available.LastTransitionTime.Time) > unavailableReplicasGracePeriod {
+			return v1alpha1.ResourceHealthDegraded, message
+		}
+		return v1alpha1.ResourceHealthProgressing, message
+	}
+	return v1alpha1.ResourceHealthHealthy, ""
+}
+
+func statefulSetHealth(statefulSet *appsv1.StatefulSet) (v1alpha1.ResourceHealth, string) {
+	if statefulSet.Status.ObservedGeneration < statefulSet.GetGeneration() {
+		return v1alpha1.ResourceHealthProgressing, "waiting for the rollout to be observed"
+	}
+
+	replicas := ptr.Deref(statefulSet.Spec.Replicas, 1)
+	// with a partition, only the replicas with an ordinal of at least the partition are updated
+	updatedReplicas := replicas
+	if rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil {
+		updatedReplicas = max(0, replicas-ptr.Deref(rollingUpdate.Partition, 0))
+	}
+	switch status := statefulSet.Status; {
+	case status.ReadyReplicas < replicas:
+		return v1alpha1.ResourceHealthProgressing,
+			fmt.Sprintf("%d of %d replicas are ready", status.ReadyReplicas, replicas)
+	case statefulSet.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType &&
+		status.UpdatedReplicas < updatedReplicas:
+		return v1alpha1.ResourceHealthProgressing,
+			fmt.Sprintf("%d of %d replicas are updated", status.UpdatedReplicas, updatedReplicas)
+	}
+	return v1alpha1.ResourceHealthHealthy, ""
+}
+
+func daemonSetHealth(daemonSet *appsv1.DaemonSet) (v1alpha1.ResourceHealth, string) {
+	if daemonSet.Status.ObservedGeneration < daemonSet.GetGeneration() {
+		return v1alpha1.ResourceHealthProgressing, "waiting for the rollout to be observed"
+	}
+
+	switch status := daemonSet.Status; {
+	case daemonSet.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType &&
+		status.UpdatedNumberScheduled < status.DesiredNumberScheduled:
+		return v1alpha1.ResourceHealthProgressing,
+			fmt.Sprintf("%d of %d pods are updated", status.UpdatedNumberScheduled, status.DesiredNumberScheduled)
+	case status.NumberAvailable < status.DesiredNumberScheduled:
+		return v1alpha1.ResourceHealthProgressing,
+			fmt.Sprintf("%d of %d pods are available", status.NumberAvailable, status.DesiredNumberScheduled)
+	}
+	return v1alpha1.ResourceHealthHealthy, ""
+}
+
+func jobHealth(job *batchv1.Job) (v1alpha1.ResourceHealth, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return v1alpha1.ResourceHealthHealthy, ""
+		case batchv1.JobFailed:
+			return v1alpha1.ResourceHealthDegraded, condition.Message
+		case batchv1.JobSuspended:
+			return v1alpha1.ResourceHealthHealthy, "job is suspended"
+		}
+	}
+	return v1alpha1.ResourceHealthProgressing, "waiting for the job to complete"
+}
+
+func podHealth(pod *corev1.Pod) (v1alpha1.ResourceHealth, string) {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return v1alpha1.ResourceHealthHealthy, ""
+	case corev1.PodFailed:
+		return v1alpha1.ResourceHealthDegraded, pod.Status.Message
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return v1alpha1.ResourceHealthHealthy, ""
+		}
+	}
+	return v1alpha1.ResourceHealthProgressing, "waiting for the pod to become ready"
+}
+
+func persistentVolumeClaimHealth(claim *corev1.PersistentVolumeClaim) (v1alpha1.ResourceHealth, string) {
+	switch claim.Status.Phase {
+	case corev1.ClaimBound:
+		return v1alpha1.ResourceHealthHealthy, ""
+	case corev1.ClaimLost:
+		return v1alpha1.ResourceHealthDegraded, "the bound volume was lost"
+	default:
+		// a claim which stays pending needs user action, like a missing storage class or volume
+		if time.Since(claim.GetCreationTimestamp().Time) > pendingClaimGracePeriod {
+			return v1alpha1.ResourceHealthDegraded, "no volume is bound to the claim"
+		}
+		return v1alpha1.ResourceHealthProgressing, "waiting for a volume to be bound to the claim"
+	}
+}
+
+// isWaitingForFirstConsumer reports whether obj is a pending PersistentVolumeClaim of a storage class with
+// the WaitForFirstConsumer volume binding mode, whose volume is only bound once a Pod using it is scheduled.
+// Such claims are progressing without a time limit, and later sync waves are applied without waiting for them,
+// as they may contain the consumer of the claim.
+func (r *SampleReconciler) isWaitingForFirstConsumer(ctx context.Context, obj *unstructured.Unstructured) bool {
+	if obj.GroupVersionKind().GroupKind() != (schema.GroupKind{Kind: "PersistentVolumeClaim"}) {
+		return false
+	}
+	claim := &corev1.PersistentVolumeClaim{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, claim); err != nil {
+		return false
+	}
+	if claim.Status.Phase == corev1.ClaimBound || claim.Status.Phase == corev1.ClaimLost ||
+		ptr.Deref(claim.Spec.StorageClassName, "") == "" {
+		return false
+	}
+	if _, found := claim.GetAnnotations()[selectedNodeAnnotation]; found {
+		return false
+	}
+
+	storageClass := &storagev1.StorageClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: *claim.Spec.StorageClassName}, storageClass); err != nil {
+		// the claim is evaluated like claims of other storage classes
+		log.FromContext(ctx).V(debugLogLevel).Info("storage class of claim could not be read",
+			"resource", inventoryEntryOf(obj).String(), "error", err.Error())
+		return false
+	}
+	return ptr.Deref(storageClass.VolumeBindingMode, storagev1.VolumeBindingImmediate) ==
+		storagev1.VolumeBindingWaitForFirstConsumer
+}
+
+func serviceHealth(service *corev1.Service) (v1alpha1.ResourceHealth, string) {
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) == 0 {
+		return v1alpha1.ResourceHealthProgressing, "waiting for the load balancer to be provisioned"
+	}
+	return v1alpha1.ResourceHealthHealthy, ""
+}
+
+func namespaceHealth(namespace *corev1.Namespace) (v1alpha1.ResourceHealth, string) {
+	if namespace.Status.Phase == corev1.NamespaceTerminating {
+		return v1alpha1.ResourceHealthProgressing, "namespace is terminating"
+	}
+	return v1alpha1.ResourceHealthHealthy, ""
+}
+
+func customResourceDefinitionHealth(obj *unstructured.Unstructured) (v1alpha1.ResourceHealth, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if condition := findCondition(conditions, "NamesAccepted"); condition != nil && condition.status == "False" {
+		return v1alpha1.ResourceHealthDegraded, condition.describe()
+	}
+	if condition := findCondition(conditions, "Established"); condition != nil && condition.status == "True" {
+		return v1alpha1.ResourceHealthHealthy, ""
+	}
+	return v1alpha1.ResourceHealthProgressing, "waiting for the resource definition to be established"
+}
+
+// resourceCondition is a condition of the status of an unstructured resource.
+type resourceCondition struct {
+	conditionType, status, reason, message string
+}
+
+func (c *resourceCondition) describe() string {
+	if c.message == "" {
+		return fmt.Sprintf("condition %s is %s: %s", c.conditionType, c.status, c.reason)
+	}
+	return fmt.Sprintf("condition %s is %s: %s", c.conditionType, c.status, c.message)
+}
+
+func findCondition(conditions []interface{}, conditionType string) *resourceCondition {
+	for _, condition := range conditions {
+		fields, ok := condition.(map[string]interface{})
+		if !ok || fields["type"] != conditionType {
+			continue
+		}
+		found := &resourceCondition{conditionType: conditionType}
+		found.status, _ = fields["status"].(string)
+		found.reason, _ = fields["reason"].(string)
+		found.message, _ = fields["message"].(string)
+		return found
+	}
+	return nil
+}
+
+// newResourceStatus returns the status of a resource of the manifest after it was applied,
+// or after applying it failed with applyErr.
+func newResourceStatus(obj *unstructured.Unstructured, applyErr error, rules healthRules) v1alpha1.ResourceStatus {
+	resourceStatus := v1alpha1.ResourceStatus{InventoryEntry: inventoryEntryOf(obj)}
+	if applyErr != nil {
+		resourceStatus.ApplyResult = v1alpha1.ApplyResultFailed
+		resourceStatus.Health = v1alpha1.ResourceHealthUnknown
+		resourceStatus.Message = truncateMessage(applyErr.Error())
+		return resourceStatus
+	}
+
+	resourceStatus.ApplyResult = v1alpha1.ApplyResultApplied
+	health, message := rules.resourceHealth(obj)
+	resourceStatus.Health = health
+	resourceStatus.Message = truncateMessage(message)
+	return resourceStatus
+}
+
+// setResourceStatuses sets the resources of status, listing resources which failed to apply or
+// are not healthy first, limited to v1alpha1.MaxResourceStatuses, and summarizes their health.
+func setResourceStatuses(status *v1alpha1.SampleStatus, resources []v1alpha1.ResourceStatus) {
+	healthy := 0
+	for _, resource := range resources {
+		if resource.ApplyResult == v1alpha1.ApplyResultApplied && resource.Health == v1alpha1.ResourceHealthHealthy {
+			healthy++
+		}
+	}
+	status.ResourcesReady = fmt.Sprintf("%d/%d", healthy, len(resources))
+
+	sorted := make([]v1alpha1.ResourceStatus, len(resources))
+	copy(sorted, resources)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return resourceSeverity(sorted[i]) > resourceSeverity(sorted[j])
+	})
+	status.Resources = sorted[:min(len(sorted), v1alpha1.MaxResourceStatuses)]
+}
+
+// resourceSeverity orders resources by how urgently they need attention.
+func resourceSeverity(resource v1alpha1.ResourceStatus) int {
+	if resource.ApplyResult == v1alpha1.ApplyResultFailed {
+		return len(healthSeverity)
+	}
+	return healthSeverity[resource.Health]
+}
+
+func truncateMessage(message string) string {
+	if len(message) <= maxResourceMessageLength {
+		return message
+	}
+	return message[:maxResourceMessageLength-3] + "..."
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// Applied resources are labeled with the UID of the Sample they belong to, so events of watched resources
+// can be mapped back to it through the sampleUIDIndex. Unlike names, which may be up to 253 characters long,
+// UIDs always fit into the 63 characters of a label value.
+const (
+	sampleUIDLabel = "operator.kyma-project.io/sample-uid"
+	// sampleUIDIndex indexes Samples by their UID.
+	sampleUIDIndex = "metadata.uid"
+)
+
+// resourceWatches registers a watch for each kind of resource applied by the controller, once.
+// Watches only receive the metadata of resources, so the whole resources of a kind are not cached.
+type resourceWatches struct {
+	mu         sync.Mutex
+	controller controller.Controller
+	cache      cache.Cache
+	requests   requestsForKind
+	kinds      map[schema.GroupVersionKind]struct{}
+}
+
+// requestsForKind returns the function which maps the watched resources of a kind to the requests they trigger.
+type requestsForKind func(gvk schema.GroupVersionKind) handler.TypedMapFunc[*metav1.PartialObjectMetadata,
+	reconcile.Request]
+
+func newResourceWatches(c controller.Controller, informers cache.Cache, requests requestsForKind,
+) *resourceWatches {
+	return &resourceWatches{
+		controller: c, cache: informers, requests: requests, kinds: make(map[schema.GroupVersionKind]struct{}),
+	}
+}
+
+// watch registers watches for the kinds which are not watched yet.
+func (w *resourceWatches) watch(ctx context.Context, gvks ...schema.GroupVersionKind) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, gvk := range gvks {
+		if _, found := w.kinds[gvk]; found {
+			continue
+		}
+		metadata := &metav1.PartialObjectMetadata{}
+		metadata.SetGroupVersionKind(gvk)
+		if err := w.controller.Watch(source.Kind(w.cache, metadata,
+			handler.TypedEnqueueRequestsFromMapFunc(w.requests(gvk)))); err != nil {
+			return fmt.Errorf("watch for %s could not be registered: %w", gvk, err)
+		}
+		w.kinds[gvk] = struct{}{}
+		log.FromContext(ctx).V(debugLogLevel).Info("watching applied resources", "kind", gvk.String())
+	}
+	return nil
+}
+
+// watchAppliedResources watches the kinds of the applied objs, so changes to them are reconciled
+// without waiting for the next resync. Watches are only registered once the controller is set up.
+func (r *SampleReconciler) watchAppliedResources(ctx context.Context, objs []*unstructured.Unstructured) {
+	if r.watches == nil {
+		return
+	}
+	gvks := make([]schema.GroupVersionKind, 0, len(objs))
+	for _, obj := range objs {
+		gvks = append(gvks, obj.GroupVersionKind())
+	}
+	if err := r.watches.watch(ctx, gvks...); err != nil {
+		// changes to the resources are still reconciled with the next resync
+		log.FromContext(ctx).Error(err, "error watching applied resources")
+	}
+}
+
+// setSampleLabels labels obj with the reconciled resource it is applied for.
+func setSampleLabels(obj *unstructured.Unstructured, objectInstance *v1alpha1.Sample) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[sampleUIDLabel] = string(objectInstance.GetUID())
+	obj.SetLabels(labels)
+}
+
+func indexSampleUID(obj client.Object) []string {
+	return []string{string(obj.GetUID())}
+}
+
+// requestsForAppliedObject maps applied resources of all kinds to the Sample with the UID they are labeled with.
+func (r *SampleReconciler) requestsForAppliedObject(schema.GroupVersionKind,
+) handler.TypedMapFunc[*metav1.PartialObjectMetadata, reconcile.Request] {
+	return r.requestsForSampleUID
+}
+
+func (r *SampleReconciler) requestsForSampleUID(ctx context.Context, obj *metav1.PartialObjectMetadata,
+) []reconcile.Request {
+	uid, found := obj.GetLabels()[sampleUIDLabel]
+	if !found {
+		return nil
+	}
+	samples := &v1alpha1.SampleList{}
+	if err := r.List(ctx, samples, client.MatchingFields{sampleUIDIndex: uid}); err != nil {
+		log.FromContext(ctx).Error(err, "error listing samples of "+obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(samples.Items))
+	for i := range samples.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&samples.Items[i])})
+	}
+	return requests
+}
@@ -0,0 +1,85 @@
+package controllers_test
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	orderedWidgetName = "ordered-widget"
+	orderedManifest   = `apiVersion: ordering.kyma-project.io/v1
+kind: Widget
+metadata:
+  name: ` + orderedWidgetName + `
+  namespace: default
+spec:
+  size: 3
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.ordering.kyma-project.io
+spec:
+  group: ordering.kyma-project.io
+  names:
+    kind: Widget
+    listKind: WidgetList
+    plural: widgets
+    singular: widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+`
+)
+
+var _ = Describe("Sample CR is created with a custom resource listed before its definition", Ordered, func() {
+	sampleCR := createSampleCR("apply-order-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{"resources.yaml": orderedManifest})
+	})
+
+	It("should apply the definition first and install the custom resource", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		widget := &unstructured.Unstructured{}
+		widget.SetGroupVersionKind(schema.GroupVersionKind{Group: "ordering.kyma-project.io", Version: "v1", Kind: "Widget"})
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: orderedWidgetName},
+			widget)).To(Succeed())
+
+		inventory := getInventory(sampleCRKey)
+		Expect(inventory).To(HaveLen(2))
+		Expect(inventory[0].Kind).To(Equal("CustomResourceDefinition"))
+		Expect(inventory[1].Kind).To(Equal("Widget"))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
@@ -0,0 +1,146 @@
+package controllers_test
+
+import (
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	blockedConfigMapName            = "blocked-config"
+	blockingThirdParty              = "blocking-third-party"
+	blockedInventoryConfigMapName   = "blocked-inventory-config"
+	blockingInventoryThirdPartyName = "blocking-inventory-third-party"
+)
+
+var _ = Describe("Sample CR is deleted while ThirdParty resources exist", Ordered, func() {
+	sampleCR := createSampleCR("deletion-blocked-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	thirdParty := &unstructured.Unstructured{}
+	thirdParty.SetGroupVersionKind(v1alpha1.GroupVersion.WithKind("ThirdParty"))
+	thirdParty.SetNamespace(metav1.NamespaceDefault)
+	thirdParty.SetName(blockingThirdParty)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": pruneConfigMapManifest(blockedConfigMapName, ""),
+		})
+	})
+
+	It("should install the resources", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(k8sClient.Create(ctx, thirdParty)).To(Succeed())
+	})
+
+	It("should keep the resources and set state to Warning while the ThirdParty exists", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+
+		Eventually(func() v1alpha1.State { return getSampleStatus(sampleCRKey).State }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.StateWarning))
+		condition := meta.FindStatusCondition(getSampleStatus(sampleCRKey).Conditions,
+			v1alpha1.ConditionTypeDeletionBlocked)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring(blockingThirdParty))
+
+		Consistently(func() v1alpha1.State { return getSampleStatus(sampleCRKey).State }).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.StateWarning))
+		Expect(configMapExists(blockedConfigMapName)).To(BeTrue())
+		Expect(getEventCount(sampleCRKey, "Deleting")).To(Equal(int32(1)))
+	})
+
+	It("should delete the resources and the SampleCR once the ThirdParty is removed", func() {
+		Expect(k8sClient.Delete(ctx, thirdParty)).To(Succeed())
+
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists(blockedConfigMapName)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Sample CR is deleted after its manifest was removed while ThirdParty resources exist", Ordered, func() {
+	sampleCR := createSampleCR("inventory-deletion-blocked-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	thirdParty := &unstructured.Unstructured{}
+	thirdParty.SetGroupVersionKind(v1alpha1.GroupVersion.WithKind("ThirdParty"))
+	thirdParty.SetNamespace(metav1.NamespaceDefault)
+	thirdParty.SetName(blockingInventoryThirdPartyName)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": pruneConfigMapManifest(blockedInventoryConfigMapName, ""),
+		})
+	})
+
+	It("should install the resources", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(k8sClient.Create(ctx, thirdParty)).To(Succeed())
+	})
+
+	It("should keep the resources of the inventory while the ThirdParty exists", func() {
+		Expect(os.RemoveAll(sampleCR.Spec.ResourceFilePath)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+
+		Eventually(func() bool {
+			return meta.IsStatusConditionTrue(getSampleStatus(sampleCRKey).Conditions,
+				v1alpha1.ConditionTypeDeletionBlocked)
+		}).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(getSampleStatus(sampleCRKey).State).To(Equal(v1alpha1.StateWarning))
+
+		Consistently(func() bool { return configMapExists(blockedInventoryConfigMapName) }).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+
+	It("should delete the resources of the inventory and the SampleCR once the ThirdParty is removed", func() {
+		Expect(k8sClient.Delete(ctx, thirdParty)).To(Succeed())
+
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists(blockedInventoryConfigMapName)).To(BeFalse())
+	})
+})
+
+// getEventCount returns how often an event with reason was recorded for the Sample with key.
+func getEventCount(key client.ObjectKey, reason string) int32 {
+	events := &corev1.EventList{}
+	Expect(k8sClient.List(ctx, events, client.InNamespace(key.Namespace))).To(Succeed())
+	var count int32
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind == "Sample" && event.InvolvedObject.Name == key.Name && event.Reason == reason {
+			count += event.Count
+		}
+	}
+	return count
+}
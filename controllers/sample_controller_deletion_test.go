@@ -0,0 +1,225 @@
+package controllers_test
+
+import (
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	deletionGadgetName      = "deletion-gadget"
+	deletionConfigMapName   = "deletion-config"
+	deletionGadgetFinalizer = "test.kyma-project.io/hold"
+	deletionCRDName         = "gadgets.deletion.kyma-project.io"
+	deletionManifest        = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: ` + deletionCRDName + `
+spec:
+  group: deletion.kyma-project.io
+  names:
+    kind: Gadget
+    listKind: GadgetList
+    plural: gadgets
+    singular: gadget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+---
+apiVersion: deletion.kyma-project.io/v1
+kind: Gadget
+metadata:
+  name: ` + deletionGadgetName + `
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + deletionConfigMapName + `
+  namespace: default
+`
+	inventoryDeletionGadgetName    = "inventory-deletion-gadget"
+	inventoryDeletionConfigMapName = "inventory-deletion-config"
+	inventoryDeletionCRDName       = "gadgets.inventory-deletion.kyma-project.io"
+	inventoryDeletionManifest      = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: ` + inventoryDeletionCRDName + `
+spec:
+  group: inventory-deletion.kyma-project.io
+  names:
+    kind: Gadget
+    listKind: GadgetList
+    plural: gadgets
+    singular: gadget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+---
+apiVersion: inventory-deletion.kyma-project.io/v1
+kind: Gadget
+metadata:
+  name: ` + inventoryDeletionGadgetName + `
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + inventoryDeletionConfigMapName + `
+  namespace: default
+`
+)
+
+var _ = Describe("Sample CR is deleted while a custom resource is still terminating", Ordered, func() {
+	sampleCR := createSampleCR("deletion-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	gadgetKey := client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: deletionGadgetName}
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{"resources.yaml": deletionManifest})
+	})
+
+	It("should install all resources", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		gadget := getGadget(gadgetKey)
+		controllerutil.AddFinalizer(gadget, deletionGadgetFinalizer)
+		Expect(k8sClient.Update(ctx, gadget)).To(Succeed())
+	})
+
+	It("should keep the definition, earlier resources and the finalizer until the custom resource is gone", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+
+		Eventually(func() string { return getInstallCondition(sampleCRKey).Reason }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.ConditionReasonResourcesDeleting))
+		Expect(getInstallCondition(sampleCRKey).Message).To(ContainSubstring(deletionGadgetName))
+		Expect(getGadget(gadgetKey).GetDeletionTimestamp().IsZero()).To(BeFalse())
+
+		Consistently(func() bool { return configMapExists(deletionConfigMapName) }).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(schema.GroupVersionKind{
+			Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition",
+		})
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: deletionCRDName}, crd)).To(Succeed())
+		Expect(crd.GetDeletionTimestamp().IsZero()).To(BeTrue())
+		Expect(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})).To(Succeed())
+	})
+
+	It("should delete the remaining resources and the SampleCR once the custom resource is gone", func() {
+		gadget := getGadget(gadgetKey)
+		controllerutil.RemoveFinalizer(gadget, deletionGadgetFinalizer)
+		Expect(k8sClient.Update(ctx, gadget)).To(Succeed())
+
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists(deletionConfigMapName)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Sample CR is deleted after its manifest was removed", Ordered, func() {
+	sampleCR := createSampleCR("inventory-deletion-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	gadgetKey := client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: inventoryDeletionGadgetName}
+	gadgetGroup := "inventory-deletion.kyma-project.io"
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"resources.yaml": inventoryDeletionManifest,
+		})
+	})
+
+	It("should install all resources", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		gadget := getGadgetOf(gadgetGroup, gadgetKey)
+		controllerutil.AddFinalizer(gadget, deletionGadgetFinalizer)
+		Expect(k8sClient.Update(ctx, gadget)).To(Succeed())
+	})
+
+	It("should delete the resources of the inventory in reverse apply order", func() {
+		Expect(os.RemoveAll(sampleCR.Spec.ResourceFilePath)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+
+		Eventually(func() string { return getInstallCondition(sampleCRKey).Reason }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.ConditionReasonResourcesDeleting))
+		Expect(getInstallCondition(sampleCRKey).Message).To(ContainSubstring(inventoryDeletionGadgetName))
+		Expect(getGadgetOf(gadgetGroup, gadgetKey).GetDeletionTimestamp().IsZero()).To(BeFalse())
+
+		Consistently(func() bool { return configMapExists(inventoryDeletionConfigMapName) }).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})).To(Succeed())
+	})
+
+	It("should delete the remaining resources and the SampleCR once the custom resource is gone", func() {
+		gadget := getGadgetOf(gadgetGroup, gadgetKey)
+		controllerutil.RemoveFinalizer(gadget, deletionGadgetFinalizer)
+		Expect(k8sClient.Update(ctx, gadget)).To(Succeed())
+
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists(inventoryDeletionConfigMapName)).To(BeFalse())
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(schema.GroupVersionKind{
+			Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition",
+		})
+		err := k8sClient.Get(ctx, client.ObjectKey{Name: inventoryDeletionCRDName}, crd)
+		Expect(errors.IsNotFound(err) || !crd.GetDeletionTimestamp().IsZero()).To(BeTrue())
+	})
+})
+
+func getGadget(key client.ObjectKey) *unstructured.Unstructured {
+	return getGadgetOf("deletion.kyma-project.io", key)
+}
+
+func getGadgetOf(group string, key client.ObjectKey) *unstructured.Unstructured {
+	gadget := &unstructured.Unstructured{}
+	gadget.SetGroupVersionKind(schema.GroupVersionKind{Group: group, Version: "v1", Kind: "Gadget"})
+	Expect(k8sClient.Get(ctx, key, gadget)).To(Succeed())
+	return gadget
+}
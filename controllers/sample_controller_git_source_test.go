@@ -0,0 +1,257 @@
+package controllers_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	gitConfigMapName = "git-config"
+	gitBranch        = "main"
+	gitManifestPath  = "manifests/configmap.yaml"
+)
+
+var _ = Describe("Sample CR is created with a manifest from a git repository", Ordered, func() {
+	var repoDir string
+	var repo *git.Repository
+	var firstCommit plumbing.Hash
+
+	sampleCR := createSampleCR("git-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		repoDir = GinkgoT().TempDir()
+		var err error
+		repo, err = git.PlainInitWithOptions(repoDir, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(gitBranch)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		firstCommit = commitGitFile(repo, repoDir, gitManifestPath, gitConfigMapManifest("v1"))
+		commitGitFile(repo, repoDir, "README.md", "not a manifest")
+
+		sampleCR.Spec.Source = &v1alpha1.ManifestSource{Git: &v1alpha1.GitSource{
+			URL:    "file://" + repoDir,
+			Branch: gitBranch,
+			Path:   filepath.Dir(gitManifestPath),
+		}}
+	})
+
+	It("should install the manifest at the path of the tracked branch", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(getGitConfigMapVersion()).To(Equal("v1"))
+
+		source := getSourceStatus(sampleCRKey)
+		Expect(source.Type).To(Equal("Git"))
+		head, err := repo.Head()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(source.Commit).To(Equal(head.Hash().String()))
+		Expect(source.Commit).NotTo(Equal(firstCommit.String()))
+	})
+
+	It("should install a new commit on the tracked branch", func() {
+		newCommit := commitGitFile(repo, repoDir, gitManifestPath, gitConfigMapManifest("v2"))
+
+		Eventually(getGitConfigMapVersion).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal("v2"))
+		Eventually(func() string { return getSourceStatus(sampleCRKey).Commit }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(newCommit.String()))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool {
+			return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{}))
+		}).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists(gitConfigMapName)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Sample CR is created with a manifest from a git repository and a long resync interval", Ordered,
+	func() {
+		var repoDir string
+		var repo *git.Repository
+
+		sampleCR := createSampleCR("git-resync-sample", "")
+		sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+		BeforeAll(func() {
+			reconciler.ResyncInterval = time.Hour
+			DeferCleanup(func() {
+				reconciler.ResyncInterval = 3 * time.Second
+			})
+			repoDir = GinkgoT().TempDir()
+			var err error
+			repo, err = git.PlainInitWithOptions(repoDir, &git.PlainInitOptions{
+				InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(gitBranch)},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			commitGitFile(repo, repoDir, gitManifestPath, gitConfigMapManifest("v1"))
+
+			sampleCR.Spec.Source = &v1alpha1.ManifestSource{Git: &v1alpha1.GitSource{
+				URL:    "file://" + repoDir,
+				Branch: gitBranch,
+				Path:   filepath.Dir(gitManifestPath),
+			}}
+		})
+
+		It("should not fetch a new commit with reconciliations before the resync", func() {
+			Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+			Eventually(getCRStatus(sampleCRKey)).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+			Expect(getGitConfigMapVersion()).To(Equal("v1"))
+
+			commitGitFile(repo, repoDir, gitManifestPath, gitConfigMapManifest("v2"))
+			Expect(k8sClient.Get(ctx, sampleCRKey, sampleCR)).To(Succeed())
+			sampleCR.SetAnnotations(map[string]string{"reconcile": "now"})
+			Expect(k8sClient.Update(ctx, sampleCR)).To(Succeed())
+
+			Consistently(getGitConfigMapVersion).
+				WithTimeout(5 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(Equal("v1"))
+		})
+
+		It("should fetch the repository again after spec.source.git changed", func() {
+			head, err := repo.Head()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = repo.CreateTag("v2", head.Hash(), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, sampleCRKey, sampleCR)).To(Succeed())
+			sampleCR.Spec.Source.Git.Branch = ""
+			sampleCR.Spec.Source.Git.Tag = "v2"
+			Expect(k8sClient.Update(ctx, sampleCR)).To(Succeed())
+
+			Eventually(getGitConfigMapVersion).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(Equal("v2"))
+			Expect(getSourceStatus(sampleCRKey).Commit).To(Equal(head.Hash().String()))
+		})
+
+		It("should delete installed resources when SampleCR is deleted", func() {
+			Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{}))
+			}).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(BeTrue())
+			Expect(configMapExists(gitConfigMapName)).To(BeFalse())
+		})
+	})
+
+var _ = Describe("Sample CR is created with a git repository which is not allowed", func() {
+	DescribeTable("should end in Error state without installing the manifest",
+		func(sampleName, path, gitFileRoot string) {
+			if gitFileRoot != "" {
+				reconciler.GitFileRoot = filepath.Join(os.TempDir(), gitFileRoot)
+				Expect(os.MkdirAll(reconciler.GitFileRoot, os.ModePerm)).To(Succeed())
+				DeferCleanup(func() {
+					reconciler.GitFileRoot = os.TempDir()
+				})
+			}
+			repoDir := GinkgoT().TempDir()
+			repo, err := git.PlainInitWithOptions(repoDir, &git.PlainInitOptions{
+				InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(gitBranch)},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			commitGitFile(repo, repoDir, gitManifestPath, gitConfigMapManifest("v1"))
+			// a manifest outside of the repository, which must not be installed by a symbolic link
+			outsideDir := createManifestDir(map[string]string{"configmap.yaml": gitConfigMapManifest("outside")})
+			Expect(os.Symlink(outsideDir, filepath.Join(repoDir, "outside"))).To(Succeed())
+			worktree, err := repo.Worktree()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = worktree.Add("outside")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = worktree.Commit("add outside", &git.CommitOptions{
+				Author: &object.Signature{Name: "test", Email: "test@kyma-project.io", When: time.Now()},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			sampleCR := createSampleCR(sampleName, "")
+			sampleCR.Spec.Source = &v1alpha1.ManifestSource{Git: &v1alpha1.GitSource{
+				URL: "file://" + repoDir, Branch: gitBranch, Path: path,
+			}}
+			sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+			Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+			Eventually(getCRStatus(sampleCRKey)).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+			Expect(getInstallCondition(sampleCRKey).Reason).To(Equal(v1alpha1.ConditionReasonManifestNotFound))
+			Expect(configMapExists(gitConfigMapName)).To(BeFalse())
+
+			Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+			Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(BeTrue())
+		},
+		Entry("when the path is a symbolic link to a directory outside of the repository",
+			"git-symlink-sample", "outside", ""),
+		Entry("when the file:// remote is not below the git file root",
+			"git-file-root-sample", filepath.Dir(gitManifestPath), "git-file-root"),
+	)
+})
+
+func gitConfigMapManifest(version string) string {
+	return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + gitConfigMapName + `
+data:
+  version: ` + version + `
+`
+}
+
+func commitGitFile(repo *git.Repository, repoDir, path, content string) plumbing.Hash {
+	Expect(os.MkdirAll(filepath.Join(repoDir, filepath.Dir(path)), os.ModePerm)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(repoDir, path), []byte(content), 0o600)).To(Succeed())
+
+	worktree, err := repo.Worktree()
+	Expect(err).NotTo(HaveOccurred())
+	_, err = worktree.Add(path)
+	Expect(err).NotTo(HaveOccurred())
+	commit, err := worktree.Commit("update "+path, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@kyma-project.io", When: time.Now()},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return commit
+}
+
+func getGitConfigMapVersion() string {
+	configMap := &corev1.ConfigMap{}
+	Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: gitConfigMapName},
+		configMap)).To(Succeed())
+	return configMap.Data["version"]
+}
@@ -0,0 +1,105 @@
+package controllers_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	healthRuleConfigMapName = "health-rule-config"
+	healthRuleExpression    = "has(self.data.phase) && self.data.phase == 'Running'"
+)
+
+var _ = Describe("Sample CR is created with a health rule", Ordered, func() {
+	sampleCR := createSampleCR("health-rule-sample", "")
+	sampleCR.Spec.HealthRules = []v1alpha1.HealthRule{
+		{Version: "v1", Kind: "ConfigMap", Expression: healthRuleExpression},
+	}
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + healthRuleConfigMapName + `
+  namespace: default
+data:
+  config: value
+`,
+		})
+	})
+
+	It("should report the failing expression and resource while the rule is not satisfied", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateProcessing, InstallConditionStatus: metav1.ConditionUnknown, Err: nil}))
+		Eventually(func() string { return getInstallCondition(sampleCRKey).Reason }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.ConditionReasonResourcesNotReady))
+		message := getInstallCondition(sampleCRKey).Message
+		Expect(message).To(ContainSubstring("ConfigMap default/" + healthRuleConfigMapName))
+		Expect(message).To(ContainSubstring(healthRuleExpression))
+	})
+
+	It("should set state to Ready once the rule is satisfied", func() {
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: healthRuleConfigMapName},
+			configMap)).To(Succeed())
+		configMap.Data["phase"] = "Running"
+		Expect(k8sClient.Update(ctx, configMap)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return configMapExists(healthRuleConfigMapName) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+	})
+})
+
+var _ = Describe("Sample CR is created with an invalid health rule", Ordered, func() {
+	sampleCR := createSampleCR("invalid-health-rule-sample", "")
+	sampleCR.Spec.HealthRules = []v1alpha1.HealthRule{{Kind: "ConfigMap", Expression: "self.data.phase =="}}
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should end in Error state pointing to the invalid rule", func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": pruneConfigMapManifest("invalid-health-rule-config", ""),
+		})
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+		condition := getInstallCondition(sampleCRKey)
+		Expect(condition.Reason).To(Equal(v1alpha1.ConditionReasonHealthRuleInvalid))
+		Expect(condition.Message).To(ContainSubstring("self.data.phase =="))
+
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
@@ -0,0 +1,92 @@
+package controllers_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const chartPodName = "busybox-helm-pod"
+
+var _ = Describe("Sample CR is created with a Helm chart as resource path", Ordered, func() {
+	sampleCR := createSampleCR("helm-sample", testChartPath)
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should render the chart and create resources in the namespace of the SampleCR", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getPod(metav1.NamespaceDefault, chartPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should delete rendered resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, chartPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+var _ = Describe("Sample CR is created with a Helm chart and custom values", Ordered, func() {
+	valuesConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "helm-values", Namespace: metav1.NamespaceDefault},
+		Data: map[string]string{
+			v1alpha1.DefaultValuesKey: "image:\n  tag: \"1.35\"\n  pullPolicy: Always\n",
+		},
+	}
+	sampleCR := createSampleCR("helm-values-sample", testChartPath)
+	sampleCR.Spec.ReleaseName = "custom-release"
+	sampleCR.Spec.Values = &runtime.RawExtension{Raw: []byte(`{"image":{"tag":"1.36"}}`)}
+	sampleCR.Spec.ValuesFrom = []v1alpha1.ValuesReference{
+		{Kind: v1alpha1.ReferenceKindConfigMap, Name: valuesConfigMap.Name},
+		{Kind: v1alpha1.ReferenceKindSecret, Name: "missing-values", Optional: true},
+	}
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should render the chart with merged values and release name", func() {
+		Expect(k8sClient.Create(ctx, valuesConfigMap)).To(Succeed())
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getPod(metav1.NamespaceDefault, chartPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		pod := &corev1.Pod{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: chartPodName}, pod)).
+			To(Succeed())
+		Expect(pod.GetLabels()).To(HaveKeyWithValue("release", "custom-release"))
+		Expect(pod.Spec.Containers).To(HaveLen(1))
+		Expect(pod.Spec.Containers[0].Image).To(Equal("busybox:1.36"))
+		Expect(pod.Spec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+	})
+
+	It("should delete rendered resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, chartPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(k8sClient.Delete(ctx, valuesConfigMap)).To(Succeed())
+	})
+})
@@ -0,0 +1,253 @@
+package controllers_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	hooksConfigMapName = "hooks-config"
+	// hookTransitionTimeout is the time a hook condition has to transition, also with the race detector enabled.
+	hookTransitionTimeout = 60 * time.Second
+)
+
+var _ = Describe("Sample CR is created with hooks", Ordered, func() {
+	sampleCR := createSampleCR("hooks-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": pruneConfigMapManifest(hooksConfigMapName, ""),
+			"hooks.yaml": strings.Join([]string{
+				hookPodManifest("pre-install-hook", "pre-install", "hook-succeeded"),
+				hookPodManifest("post-install-hook", "post-install", ""),
+				hookPodManifest("pre-upgrade-hook", "pre-upgrade", ""),
+				hookPodManifest("pre-delete-hook", "pre-delete", ""),
+				hookPodManifest("post-delete-hook", "post-delete", ""),
+			}, "---\n"),
+		})
+	})
+
+	It("should run the pre-install hooks before the resources are applied", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePreInstallHooks)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(metav1.ConditionUnknown))
+		Expect(getCRStatus(sampleCRKey)(Default)).To(Equal(
+			CRStatus{State: v1alpha1.StateProcessing, InstallConditionStatus: metav1.ConditionUnknown, Err: nil}))
+		Expect(getInstallCondition(sampleCRKey).Reason).To(Equal(v1alpha1.ConditionReasonHooksRunning))
+		Expect(configMapExists(hooksConfigMapName)).To(BeFalse())
+	})
+
+	It("should apply the resources and run the post-install hooks once the pre-install hooks succeeded", func() {
+		setHookPodPhase("pre-install-hook", corev1.PodSucceeded)
+
+		// the hook conditions transition one after the other, each is awaited, as reconciliations are slow under load
+		Eventually(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePreInstallHooks)).
+			WithTimeout(hookTransitionTimeout).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(metav1.ConditionTrue))
+		Eventually(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePostInstallHooks)).
+			WithTimeout(hookTransitionTimeout).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(metav1.ConditionUnknown))
+		Expect(configMapExists(hooksConfigMapName)).To(BeTrue())
+		// the hook is deleted with the hook-succeeded policy
+		Eventually(hookPodExists("pre-install-hook")).
+			WithTimeout(hookTransitionTimeout).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+
+		setHookPodPhase("post-install-hook", corev1.PodSucceeded)
+		Eventually(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePostInstallHooks)).
+			WithTimeout(hookTransitionTimeout).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(metav1.ConditionTrue))
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(hookTransitionTimeout).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(hookPodExists("post-install-hook")()).To(BeTrue())
+		Expect(hookPodExists("pre-upgrade-hook")()).To(BeFalse())
+	})
+
+	It("should run the pre-upgrade hooks for a new generation", func() {
+		Eventually(func(g Gomega) {
+			sample := &v1alpha1.Sample{}
+			g.Expect(k8sClient.Get(ctx, sampleCRKey, sample)).To(Succeed())
+			sample.Spec.HealthRules = []v1alpha1.HealthRule{{Kind: "ConfigMap", Expression: "true"}}
+			g.Expect(k8sClient.Update(ctx, sample)).To(Succeed())
+		}).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Succeed())
+
+		Eventually(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePreUpgradeHooks)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(metav1.ConditionUnknown))
+		Expect(getInstallCondition(sampleCRKey).Reason).To(Equal(v1alpha1.ConditionReasonHooksRunning))
+
+		setHookPodPhase("pre-upgrade-hook", corev1.PodSucceeded)
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should run the pre-upgrade hooks again for a changed manifest", func() {
+		Expect(os.WriteFile(filepath.Join(sampleCR.Spec.ResourceFilePath, "configmap.yaml"),
+			[]byte(pruneConfigMapManifest(hooksConfigMapName, "true")), 0o600)).To(Succeed())
+
+		Eventually(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePreUpgradeHooks)).
+			WithTimeout(hookTransitionTimeout).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(metav1.ConditionUnknown))
+		Expect(getInstallCondition(sampleCRKey).Reason).To(Equal(v1alpha1.ConditionReasonHooksRunning))
+
+		setHookPodPhase("pre-upgrade-hook", corev1.PodSucceeded)
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(hookTransitionTimeout).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should run the delete hooks before and after the resources are deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePreDeleteHooks)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(metav1.ConditionUnknown))
+		Expect(configMapExists(hooksConfigMapName)).To(BeTrue())
+
+		setHookPodPhase("pre-delete-hook", corev1.PodSucceeded)
+		Eventually(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePostDeleteHooks)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(metav1.ConditionUnknown))
+		Expect(configMapExists(hooksConfigMapName)).To(BeFalse())
+		Expect(hookPodExists("post-install-hook")()).To(BeFalse())
+
+		setHookPodPhase("post-delete-hook", corev1.PodSucceeded)
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(k8sClient.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault, Name: "post-delete-hook",
+		}})).To(Succeed())
+	})
+})
+
+var _ = Describe("Sample CR is created with a failing hook", Ordered, func() {
+	sampleCR := createSampleCR("failing-hook-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": pruneConfigMapManifest("failing-hook-config", ""),
+			"hooks.yaml":     hookPodManifest("failing-hook", "pre-install", ""),
+		})
+	})
+
+	It("should end in Error state without applying the resources", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(hookPodExists("failing-hook")).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		setHookPodPhase("failing-hook", corev1.PodFailed)
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+		condition := getInstallCondition(sampleCRKey)
+		Expect(condition.Reason).To(Equal(v1alpha1.ConditionReasonHookFailed))
+		Expect(condition.Message).To(ContainSubstring("failing-hook"))
+		Expect(getHookCondition(sampleCRKey, v1alpha1.ConditionTypePreInstallHooks)(Default)).
+			To(Equal(metav1.ConditionFalse))
+		Expect(configMapExists("failing-hook-config")).To(BeFalse())
+	})
+
+	It("should delete the hook when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(hookPodExists("failing-hook")()).To(BeFalse())
+	})
+})
+
+func hookPodManifest(name, hookType, deletePolicy string) string {
+	manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: ` + name + `
+  namespace: default
+  annotations:
+    operator.kyma-project.io/hook: ` + hookType + `
+`
+	if deletePolicy != "" {
+		manifest += `    operator.kyma-project.io/hook-delete-policy: ` + deletePolicy + `
+`
+	}
+	return manifest + `spec:
+  restartPolicy: Never
+  containers:
+  - name: busybox
+    image: busybox:1.36
+`
+}
+
+func getHookCondition(sampleObjKey client.ObjectKey, conditionType string) func(g Gomega) metav1.ConditionStatus {
+	return func(g Gomega) metav1.ConditionStatus {
+		sample := &v1alpha1.Sample{}
+		g.Expect(k8sClient.Get(ctx, sampleObjKey, sample)).To(Succeed())
+		condition := meta.FindStatusCondition(sample.Status.Conditions, conditionType)
+		if condition == nil {
+			return ""
+		}
+		return condition.Status
+	}
+}
+
+func hookPodExists(name string) func() bool {
+	return func() bool {
+		err := k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: name}, &corev1.Pod{})
+		return err == nil
+	}
+}
+
+// setHookPodPhase completes a hook pod, as there is no kubelet running pods in envtest.
+// The update is retried, as the hook pod may be applied by a concurrent reconciliation.
+func setHookPodPhase(name string, phase corev1.PodPhase) {
+	Eventually(func(g Gomega) {
+		pod := &corev1.Pod{}
+		g.Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: name}, pod)).
+			To(Succeed())
+		pod.Status.Phase = phase
+		g.Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+	}).
+		WithTimeout(30 * time.Second).
+		WithPolling(500 * time.Millisecond).
+		Should(Succeed())
+}
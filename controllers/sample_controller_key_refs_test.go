@@ -0,0 +1,134 @@
+package controllers_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	keyRefsPodName       = "busybox-key-refs-pod"
+	keyRefsConfigMapName = "key-refs-config"
+	keyRefsConfigMapKey  = "manifest.yaml.gz"
+	keyRefsSecretKey     = "manifest.yaml"
+)
+
+var _ = Describe("Sample CR is created with manifests referenced from a ConfigMap and a Secret", Ordered, func() {
+	manifestConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "key-refs-manifest", Namespace: metav1.NamespaceDefault},
+		BinaryData: map[string][]byte{keyRefsConfigMapKey: gzipManifest(keyRefsConfigMapManifest("v1"))},
+	}
+	manifestSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "key-refs-manifest", Namespace: metav1.NamespaceDefault},
+		Data: map[string][]byte{keyRefsSecretKey: []byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: ` + keyRefsPodName + `
+spec:
+  containers:
+  - name: busybox
+    image: busybox:1.36
+`)},
+	}
+	sampleCR := createSampleCR("key-refs-sample", "")
+	sampleCR.Spec.Source = &v1alpha1.ManifestSource{KeyRefs: []v1alpha1.ManifestKeyReference{
+		{Kind: v1alpha1.ReferenceKindConfigMap, Name: manifestConfigMap.Name, Key: keyRefsConfigMapKey},
+		{Kind: v1alpha1.ReferenceKindSecret, Name: manifestSecret.Name, Key: keyRefsSecretKey},
+	}}
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should install the resources of all referenced keys", func() {
+		Expect(k8sClient.Create(ctx, manifestConfigMap)).To(Succeed())
+		Expect(k8sClient.Create(ctx, manifestSecret)).To(Succeed())
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getPod(metav1.NamespaceDefault, keyRefsPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		Expect(getKeyRefsConfigMapData()).To(Equal("v1"))
+
+		source := getSourceStatus(sampleCRKey)
+		Expect(source.Type).To(Equal("KeyRefs"))
+		Expect(source.ReferencedObjects).To(Equal([]v1alpha1.ReferencedObject{
+			{Kind: v1alpha1.ReferenceKindConfigMap, Name: manifestConfigMap.Name,
+				ResourceVersion: manifestConfigMap.GetResourceVersion()},
+			{Kind: v1alpha1.ReferenceKindSecret, Name: manifestSecret.Name,
+				ResourceVersion: manifestSecret.GetResourceVersion()},
+		}))
+	})
+
+	It("should reinstall the resources when a referenced ConfigMap changes", func() {
+		manifestConfigMap.BinaryData[keyRefsConfigMapKey] = gzipManifest(keyRefsConfigMapManifest("v2"))
+		Expect(k8sClient.Update(ctx, manifestConfigMap)).To(Succeed())
+
+		Eventually(getKeyRefsConfigMapData).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal("v2"))
+		Eventually(func() string {
+			return getSourceStatus(sampleCRKey).ReferencedObjects[0].ResourceVersion
+		}).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(manifestConfigMap.GetResourceVersion()))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, keyRefsPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists(keyRefsConfigMapName)).To(BeFalse())
+		Expect(k8sClient.Delete(ctx, manifestConfigMap)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, manifestSecret)).To(Succeed())
+	})
+})
+
+func keyRefsConfigMapManifest(version string) string {
+	return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + keyRefsConfigMapName + `
+data:
+  version: ` + version + `
+`
+}
+
+func gzipManifest(manifest string) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write([]byte(manifest))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(writer.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func getKeyRefsConfigMapData() string {
+	configMap := &corev1.ConfigMap{}
+	Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: keyRefsConfigMapName},
+		configMap)).To(Succeed())
+	return configMap.Data["version"]
+}
+
+func getSourceStatus(sampleObjKey client.ObjectKey) *v1alpha1.SourceStatus {
+	sampleCR := &v1alpha1.Sample{}
+	Expect(k8sClient.Get(ctx, sampleObjKey, sampleCR)).To(Succeed())
+	Expect(sampleCR.Status.Source).NotTo(BeNil())
+	return sampleCR.Status.Source
+}
@@ -0,0 +1,67 @@
+package controllers_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sample CR is created with a kustomize overlay as resource path", Ordered, func() {
+	const overlayPodName = "dev-busybox-pod"
+	sampleCR := createSampleCR("kustomize-sample", "./test/kustomize/overlays/dev")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should build the overlay and create resources", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getPod(metav1.NamespaceDefault, overlayPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		pod := &corev1.Pod{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: overlayPodName}, pod)).
+			To(Succeed())
+		Expect(pod.GetLabels()).To(HaveKeyWithValue("environment", "dev"))
+		Expect(pod.Spec.Containers[0].Image).To(Equal("busybox:1.36"))
+	})
+
+	It("should delete built resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, overlayPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+var _ = Describe("Sample CR is created with an invalid kustomization as resource path", Ordered, func() {
+	sampleCR := createSampleCR("invalid-kustomize-sample", "./test/kustomize/invalid")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should surface the build error in the Installation condition", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+
+		condition := getInstallCondition(sampleCRKey)
+		Expect(condition.Reason).To(Equal(v1alpha1.ConditionReasonRenderFailed))
+		Expect(condition.Message).To(ContainSubstring("missing-pod.yaml"))
+
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+	})
+})
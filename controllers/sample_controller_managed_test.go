@@ -0,0 +1,121 @@
+package controllers_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const managedConfigMapName = "managed-config"
+
+var _ = Describe("Sample CR is created with its managed resource", Ordered, func() {
+	sampleCR := createSampleCR("managed-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": pruneConfigMapManifest(managedConfigMapName, ""),
+		})
+	})
+
+	It("should create the managed resource owned by the SampleCR", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getManagedState(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.StateReady))
+		managed := getManaged(sampleCRKey)
+		Expect(managed.Spec.Resources).To(Equal([]v1alpha1.InventoryEntry{pruneInventoryEntry(managedConfigMapName)}))
+		Expect(managed.Status.ResourcesAvailable).To(Equal("1/1"))
+		sample := &v1alpha1.Sample{}
+		Expect(k8sClient.Get(ctx, sampleCRKey, sample)).To(Succeed())
+		Expect(metav1.IsControlledBy(managed, sample)).To(BeTrue())
+	})
+
+	It("should keep the managed resource in sync with the SampleCR", func() {
+		managed := getManaged(sampleCRKey)
+		managed.Spec.Resources = nil
+		Expect(k8sClient.Update(ctx, managed)).To(Succeed())
+
+		Eventually(func() []v1alpha1.InventoryEntry { return getManaged(sampleCRKey).Spec.Resources }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal([]v1alpha1.InventoryEntry{pruneInventoryEntry(managedConfigMapName)}))
+	})
+
+	It("should delete the managed resource when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Managed{}))).To(BeTrue())
+	})
+})
+
+var _ = Describe("Managed resource lists a resource which does not exist", Ordered, func() {
+	managed := &v1alpha1.Managed{
+		ObjectMeta: metav1.ObjectMeta{Name: "missing-managed", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.ManagedSpec{
+			Resources: []v1alpha1.InventoryEntry{pruneInventoryEntry("missing-managed-config")},
+		},
+	}
+	managedKey := client.ObjectKeyFromObject(managed)
+
+	It("should set state to Warning and list the missing resource", func() {
+		Expect(k8sClient.Create(ctx, managed)).To(Succeed())
+
+		Eventually(getManagedState(managedKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.StateWarning))
+		status := getManaged(managedKey).Status
+		Expect(status.ResourcesAvailable).To(Equal("0/1"))
+		Expect(status.MissingResources).To(Equal([]v1alpha1.InventoryEntry{pruneInventoryEntry("missing-managed-config")}))
+	})
+
+	It("should set state to Ready once the resource is created", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing-managed-config", Namespace: metav1.NamespaceDefault},
+		}
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+
+		// the managed resource is not requeued, it is reconciled for the watched resource
+		Eventually(getManagedState(managedKey)).
+			WithTimeout(10 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.StateReady))
+		Expect(getManaged(managedKey).Status.MissingResources).To(BeEmpty())
+
+		Expect(k8sClient.Delete(ctx, configMap)).To(Succeed())
+		Eventually(getManagedState(managedKey)).
+			WithTimeout(10 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.StateWarning))
+
+		Expect(k8sClient.Delete(ctx, managed)).To(Succeed())
+	})
+})
+
+func getManaged(key client.ObjectKey) *v1alpha1.Managed {
+	managed := &v1alpha1.Managed{}
+	Expect(k8sClient.Get(ctx, key, managed)).To(Succeed())
+	return managed
+}
+
+func getManagedState(key client.ObjectKey) func(g Gomega) v1alpha1.State {
+	return func(g Gomega) v1alpha1.State {
+		managed := &v1alpha1.Managed{}
+		g.Expect(k8sClient.Get(ctx, key, managed)).To(Succeed())
+		return managed.Status.State
+	}
+}
@@ -0,0 +1,202 @@
+package controllers_test
+
+import (
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sample CR is reconciled with an unchanged manifest", Ordered, func() {
+	sampleCR := createSampleCR("manifest-hash-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	var manifestHash string
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": pruneConfigMapManifest("manifest-hash-config", ""),
+		})
+	})
+
+	It("should store the hash of the manifest", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		manifestHash = getSampleStatus(sampleCRKey).ManifestHash
+		Expect(manifestHash).To(HavePrefix("sha256:"))
+	})
+
+	It("should skip applying the resources with the next resyncs", func() {
+		skipped := getResourceApplies("skipped")
+		Eventually(func() float64 { return getResourceApplies("skipped") }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeNumerically(">", skipped))
+		Expect(getResourceApplies("performed")).To(BeNumerically(">", 0))
+		Expect(getCRStatus(sampleCRKey)(Default)).To(Equal(
+			CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should apply the resources again once the spec changed", func() {
+		Eventually(func(g Gomega) {
+			sample := &v1alpha1.Sample{}
+			g.Expect(k8sClient.Get(ctx, sampleCRKey, sample)).To(Succeed())
+			sample.Spec.HealthRules = []v1alpha1.HealthRule{{Kind: "ConfigMap", Expression: "true"}}
+			g.Expect(k8sClient.Update(ctx, sample)).To(Succeed())
+		}).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Succeed())
+
+		Eventually(func() string { return getSampleStatus(sampleCRKey).ManifestHash }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			ShouldNot(Equal(manifestHash))
+	})
+
+	It("should delete SampleCR", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+var _ = Describe("Sample CR is reconciled after the status of a resource changed", Ordered, func() {
+	sampleCR := createSampleCR("manifest-hash-status-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	deploymentKey := client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: "manifest-hash-deployment"}
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ` + deploymentKey.Name + `
+  namespace: default
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: manifest-hash
+  template:
+    metadata:
+      labels:
+        app: manifest-hash
+    spec:
+      containers:
+      - name: busybox
+        image: busybox:1.36
+`,
+		})
+	})
+
+	It("should set state to Ready once the deployment is available", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+		Eventually(func() error { return k8sClient.Get(ctx, deploymentKey, &appsv1.Deployment{}) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Succeed())
+		setDeploymentAvailable(deploymentKey, corev1.ConditionTrue)
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should not apply the resources again when only their status changed", func() {
+		// the last requeue of Processing state is not awaited by the following checks
+		Consistently(getCRStatus(sampleCRKey)).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		performed := getResourceApplies("performed")
+
+		deployment := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, deploymentKey, deployment)).To(Succeed())
+		deployment.Status.Conditions[0].Message = "status changed"
+		Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+		Consistently(func() float64 { return getResourceApplies("performed") }).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(performed))
+		Expect(getCRStatus(sampleCRKey)(Default)).To(Equal(
+			CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should evaluate the health again once the status of a resource changed", func() {
+		setDeploymentAvailable(deploymentKey, corev1.ConditionFalse)
+
+		Eventually(func() v1alpha1.State { return getSampleStatus(sampleCRKey).State }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.StateWarning))
+		Expect(getInstallCondition(sampleCRKey).Message).To(ContainSubstring(deploymentKey.Name))
+	})
+
+	It("should delete SampleCR", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+// setDeploymentAvailable simulates a complete rollout of a deployment, after which its replicas
+// are available or not, as there is no deployment controller in envtest.
+func setDeploymentAvailable(key client.ObjectKey, available corev1.ConditionStatus) {
+	deployment := &appsv1.Deployment{}
+	Expect(k8sClient.Get(ctx, key, deployment)).To(Succeed())
+	replicas := int32(1)
+	if available != corev1.ConditionTrue {
+		replicas = 0
+	}
+	deployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: deployment.GetGeneration(),
+		Replicas:           1,
+		UpdatedReplicas:    1,
+		ReadyReplicas:      replicas,
+		AvailableReplicas:  replicas,
+		Conditions: []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "NewReplicaSetAvailable"},
+			{Type: appsv1.DeploymentAvailable, Status: available, Reason: "MinimumReplicasAvailable"},
+		},
+	}
+	Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+}
+
+// getResourceApplies returns the number of resources which were applied or skipped for all Samples.
+func getResourceApplies(result string) float64 {
+	families, err := metrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, family := range families {
+		if family.GetName() != "template_operator_resource_applies_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "result" && strings.EqualFold(label.GetValue(), result) {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package controllers_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sample CR is created with an unresolvable manifest source", func() {
+	DescribeTable("should end in Error state with a distinct Installation condition reason",
+		func(sampleName string, files map[string]string, subPath string, expectedReason string) {
+			resourcePath := filepath.Join(createManifestDir(files), subPath)
+			sampleCR := createSampleCR(sampleName, resourcePath)
+			sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+			Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+			Eventually(getCRStatus(sampleCRKey)).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+
+			condition := getInstallCondition(sampleCRKey)
+			Expect(condition.Reason).To(Equal(expectedReason))
+			Expect(condition.Message).NotTo(BeEmpty())
+
+			Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		},
+		Entry("when the directory is empty",
+			"empty-dir-sample", map[string]string{}, "",
+			v1alpha1.ConditionReasonManifestNotFound),
+		Entry("when the resource path does not exist",
+			"missing-path-sample", map[string]string{}, "missing",
+			v1alpha1.ConditionReasonManifestNotFound),
+		Entry("when the directory is both a Helm chart and a kustomization",
+			"ambiguous-sample", map[string]string{
+				"Chart.yaml":         "apiVersion: v2\nname: ambiguous\nversion: 0.1.0\n",
+				"kustomization.yaml": "resources: []\n",
+			}, "",
+			v1alpha1.ConditionReasonManifestAmbiguous),
+		Entry("when the directory contains no manifest files",
+			"unsupported-dir-sample", map[string]string{"notes.txt": "not a manifest"}, "",
+			v1alpha1.ConditionReasonManifestUnsupportedFormat),
+		Entry("when the resource path is a file in an unsupported format",
+			"unsupported-file-sample", map[string]string{"notes.txt": "not a manifest"}, "notes.txt",
+			v1alpha1.ConditionReasonManifestUnsupportedFormat),
+		Entry("when the manifest contains no valid resource",
+			"unparseable-sample", map[string]string{"invalid.yaml": "not: [valid"}, "",
+			v1alpha1.ConditionReasonManifestParseError),
+		Entry("when the manifest contains more resources than the inventory can list",
+			"too-large-sample",
+			map[string]string{"configmaps.yaml": configMapsManifest(v1alpha1.MaxInventoryEntries + 1)}, "",
+			v1alpha1.ConditionReasonManifestTooLarge),
+	)
+})
+
+var _ = Describe("Sample CR is created with a single manifest file as resource path", Ordered, func() {
+	sampleCR := createSampleCR("single-file-sample", "./test/multi-file/nested/configmap.yaml")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should create resources of the file", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(configMapExists("multi-file-nested")).To(BeTrue())
+	})
+
+	It("should delete resources of the file when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return configMapExists("multi-file-nested") }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+	})
+})
+
+// createManifestDir creates a temporary directory containing the passed files.
+func createManifestDir(files map[string]string) string {
+	dir := GinkgoT().TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(filePath), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filePath, []byte(content), 0o600)).To(Succeed())
+	}
+	return dir
+}
+
+func getInstallCondition(sampleObjKey client.ObjectKey) *metav1.Condition {
+	sampleCR := &v1alpha1.Sample{}
+	Expect(k8sClient.Get(ctx, sampleObjKey, sampleCR)).To(Succeed())
+	condition := meta.FindStatusCondition(sampleCR.Status.Conditions, v1alpha1.ConditionTypeInstallation)
+	Expect(condition).NotTo(BeNil())
+	return condition
+}
+
+// configMapsManifest returns a manifest of count ConfigMaps in one file.
+func configMapsManifest(count int) string {
+	var manifest strings.Builder
+	for i := range count {
+		fmt.Fprintf(&manifest, "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: too-large-%d\n", i)
+	}
+	return manifest.String()
+}
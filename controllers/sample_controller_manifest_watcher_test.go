@@ -0,0 +1,82 @@
+package controllers_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sample CR is installed from a changing manifest directory", Ordered, func() {
+	sampleCR := createSampleCR("manifest-watcher-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		reconciler.ResyncInterval = 0
+		DeferCleanup(func() {
+			reconciler.ResyncInterval = 3 * time.Second
+		})
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"first.yaml": pruneConfigMapManifest("manifest-watcher-first", ""),
+		})
+	})
+
+	It("should set state to Ready", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		// the last requeue of Processing state is not awaited by the following tests
+		Consistently(getCRStatus(sampleCRKey)).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should apply a manifest file added to the directory", func() {
+		Expect(os.WriteFile(filepath.Join(sampleCR.Spec.ResourceFilePath, "second.yaml"),
+			[]byte(pruneConfigMapManifest("manifest-watcher-second", "")), 0o600)).To(Succeed())
+
+		Eventually(func() bool { return configMapExists("manifest-watcher-second") }).
+			WithTimeout(10 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+
+	It("should apply the changed content of a manifest file", func() {
+		Expect(os.WriteFile(filepath.Join(sampleCR.Spec.ResourceFilePath, "first.yaml"),
+			[]byte(pruneConfigMapManifest("manifest-watcher-first", "")+"data:\n  config: changed\n"),
+			0o600)).To(Succeed())
+
+		Eventually(func(g Gomega) string {
+			configMap := &corev1.ConfigMap{}
+			g.Expect(k8sClient.Get(ctx, client.ObjectKey{
+				Namespace: metav1.NamespaceDefault, Name: "manifest-watcher-first",
+			}, configMap)).To(Succeed())
+			return configMap.Data["config"]
+		}).
+			WithTimeout(10 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal("changed"))
+	})
+
+	It("should delete SampleCR", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists("manifest-watcher-first")).To(BeFalse())
+		Expect(configMapExists("manifest-watcher-second")).To(BeFalse())
+	})
+})
@@ -0,0 +1,91 @@
+package controllers_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	multiFilePath    = "./test/multi-file"
+	multiFilePodName = "multi-file-pod"
+)
+
+var _ = Describe("Sample CR is created with a directory of multiple manifest files", Ordered, func() {
+	sampleCR := createSampleCR("multi-file-sample", multiFilePath)
+	sampleCR.Spec.Recursive = true
+	sampleCR.Spec.Exclude = []string{"excluded.yml"}
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should create resources of all selected files", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getPod(metav1.NamespaceDefault, multiFilePodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		Expect(configMapExists("multi-file-json")).To(BeTrue())
+		Expect(configMapExists("multi-file-nested")).To(BeTrue())
+		Expect(configMapExists("multi-file-excluded")).To(BeFalse())
+	})
+
+	It("should delete resources of all selected files when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, multiFilePodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists("multi-file-json")).To(BeFalse())
+		Expect(configMapExists("multi-file-nested")).To(BeFalse())
+	})
+})
+
+var _ = Describe("Sample CR is created with an index file", Ordered, func() {
+	sampleCR := createSampleCR("index-file-sample", multiFilePath)
+	sampleCR.Spec.IndexFile = "order.txt"
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should only create resources of files listed in the index file", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getPod(metav1.NamespaceDefault, multiFilePodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		Expect(configMapExists("multi-file-excluded")).To(BeTrue())
+		Expect(configMapExists("multi-file-json")).To(BeFalse())
+	})
+
+	It("should delete listed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, multiFilePodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists("multi-file-excluded")).To(BeFalse())
+	})
+})
+
+func configMapExists(name string) bool {
+	err := k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: name}, &corev1.ConfigMap{})
+	Expect(client.IgnoreNotFound(err)).NotTo(HaveOccurred())
+	return !errors.IsNotFound(err)
+}
@@ -0,0 +1,170 @@
+package controllers_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	ociPodName      = "busybox-oci-pod"
+	ociTag          = "1.0.0"
+	yamlLayerMedia  = "application/x-yaml"
+	ociLayerPodYAML = `apiVersion: v1
+kind: Pod
+metadata:
+  name: ` + ociPodName + `
+spec:
+  containers:
+  - name: busybox
+    image: busybox:1.36
+`
+)
+
+var _ = Describe("Sample CR is created with a manifest from an OCI image layout", Ordered, func() {
+	var layoutPath string
+	var tarImageDigest, yamlImageDigest digest.Digest
+
+	BeforeAll(func() {
+		layoutPath = GinkgoT().TempDir()
+		tarLayer := writeOCIBlob(layoutPath, ocispecv1.MediaTypeImageLayerGzip,
+			tarGzipLayer(map[string]string{"manifests/pod.yaml": ociLayerPodYAML, "README.md": "ignored"}))
+		yamlLayer := writeOCIBlob(layoutPath, yamlLayerMedia, []byte(ociLayerPodYAML))
+		tarImage := writeOCIImage(layoutPath, tarLayer)
+		yamlImage := writeOCIImage(layoutPath, yamlLayer)
+		tarImageDigest, yamlImageDigest = tarImage.Digest, yamlImage.Digest
+
+		tarImage.Annotations = map[string]string{ocispecv1.AnnotationRefName: ociTag}
+		writeOCIIndex(layoutPath, tarImage, yamlImage)
+	})
+
+	DescribeTable("should install the manifest of the selected image",
+		func(sampleName string, ociSource func() *v1alpha1.OCISource, expectedDigest func() digest.Digest) {
+			sampleCR := createSampleCR(sampleName, "")
+			sampleCR.Spec.Source = &v1alpha1.ManifestSource{OCI: ociSource()}
+			sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+			Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+			Eventually(getPod(metav1.NamespaceDefault, ociPodName)).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(BeTrue())
+			Eventually(getCRStatus(sampleCRKey)).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+			source := getSourceStatus(sampleCRKey)
+			Expect(source.Type).To(Equal("OCI"))
+			Expect(source.Digest).To(Equal(expectedDigest().String()))
+
+			Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+			Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, ociPodName)).
+				WithTimeout(30 * time.Second).
+				WithPolling(500 * time.Millisecond).
+				Should(BeTrue())
+		},
+		Entry("when selected by tag with a tar layer",
+			"oci-tag-sample",
+			func() *v1alpha1.OCISource { return &v1alpha1.OCISource{LayoutPath: layoutPath, Tag: ociTag} },
+			func() digest.Digest { return tarImageDigest }),
+		Entry("when selected by digest with a YAML layer",
+			"oci-digest-sample",
+			func() *v1alpha1.OCISource {
+				return &v1alpha1.OCISource{LayoutPath: layoutPath, Digest: yamlImageDigest.String()}
+			},
+			func() digest.Digest { return yamlImageDigest }),
+	)
+
+	It("should end in Error state when a layer does not match its digest", func() {
+		yamlLayer := writeOCIBlob(layoutPath, yamlLayerMedia, []byte(ociLayerPodYAML+"# tampered\n"))
+		Expect(os.WriteFile(filepath.Join(layoutPath, ocispecv1.ImageBlobsDir, "sha256", yamlLayer.Digest.Encoded()),
+			[]byte(ociLayerPodYAML+"# modified\n"), 0o600)).To(Succeed())
+		tamperedImage := writeOCIImage(layoutPath, yamlLayer)
+
+		sampleCR := createSampleCR("oci-tampered-sample", "")
+		sampleCR.Spec.Source = &v1alpha1.ManifestSource{OCI: &v1alpha1.OCISource{
+			LayoutPath: layoutPath, Digest: tamperedImage.Digest.String(),
+		}}
+		sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+		writeOCIIndex(layoutPath, tamperedImage)
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+		Expect(getInstallCondition(sampleCRKey).Reason).To(Equal(v1alpha1.ConditionReasonManifestDigestMismatch))
+
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, ociPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+func writeOCIBlob(layoutPath, mediaType string, content []byte) ocispecv1.Descriptor {
+	blobDigest := digest.FromBytes(content)
+	blobDir := filepath.Join(layoutPath, ocispecv1.ImageBlobsDir, blobDigest.Algorithm().String())
+	Expect(os.MkdirAll(blobDir, os.ModePerm)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(blobDir, blobDigest.Encoded()), content, 0o600)).To(Succeed())
+	return ocispecv1.Descriptor{MediaType: mediaType, Digest: blobDigest, Size: int64(len(content))}
+}
+
+func writeOCIImage(layoutPath string, layers ...ocispecv1.Descriptor) ocispecv1.Descriptor {
+	config := writeOCIBlob(layoutPath, ocispecv1.MediaTypeEmptyJSON, []byte("{}"))
+	manifest, err := json.Marshal(ocispecv1.Manifest{
+		Versioned: ocispec.Versioned{SchemaVersion: 2},
+		MediaType: ocispecv1.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    layers,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return writeOCIBlob(layoutPath, ocispecv1.MediaTypeImageManifest, manifest)
+}
+
+func writeOCIIndex(layoutPath string, manifests ...ocispecv1.Descriptor) {
+	layout, err := json.Marshal(ocispecv1.ImageLayout{Version: ocispecv1.ImageLayoutVersion})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(os.WriteFile(filepath.Join(layoutPath, ocispecv1.ImageLayoutFile), layout, 0o600)).To(Succeed())
+
+	index, err := json.Marshal(ocispecv1.Index{
+		Versioned: ocispec.Versioned{SchemaVersion: 2},
+		MediaType: ocispecv1.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(os.WriteFile(filepath.Join(layoutPath, ocispecv1.ImageIndexFile), index, 0o600)).To(Succeed())
+}
+
+func tarGzipLayer(files map[string]string) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		Expect(tarWriter.WriteHeader(&tar.Header{
+			Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg,
+		})).To(Succeed())
+		_, err := tarWriter.Write([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tarWriter.Close()).To(Succeed())
+	Expect(gzipWriter.Close()).To(Succeed())
+	return buf.Bytes()
+}
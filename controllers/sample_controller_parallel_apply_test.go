@@ -0,0 +1,83 @@
+package controllers_test
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const parallelApplyConfigMaps = 8
+
+var _ = Describe("Sample CR is created with resources applied in parallel", Ordered, func() {
+	sampleCR := createSampleCR("parallel-apply-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		files := map[string]string{
+			"a-missing-namespace.yaml": missingNamespaceConfigMapManifest("parallel-apply-missing-a"),
+			"z-missing-namespace.yaml": missingNamespaceConfigMapManifest("parallel-apply-missing-z"),
+		}
+		for i := range parallelApplyConfigMaps {
+			files[fmt.Sprintf("configmap-%d.yaml", i)] = pruneConfigMapManifest(parallelApplyConfigMapName(i), "")
+		}
+		sampleCR.Spec.ResourceFilePath = createManifestDir(files)
+	})
+
+	It("should apply all resources and report all resources which failed to apply", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+		message := getInstallCondition(sampleCRKey).Message
+		Expect(message).To(ContainSubstring("parallel-apply-missing-a"))
+		Expect(message).To(ContainSubstring("parallel-apply-missing-z"))
+		for i := range parallelApplyConfigMaps {
+			Expect(configMapExists(parallelApplyConfigMapName(i))).To(BeTrue())
+		}
+
+		status := getSampleStatus(sampleCRKey)
+		Expect(status.ResourcesReady).To(Equal(fmt.Sprintf("%d/%d", parallelApplyConfigMaps, parallelApplyConfigMaps+2)))
+		Expect(status.Resources[0].Name).To(Equal("parallel-apply-missing-a"))
+		Expect(status.Resources[0].ApplyResult).To(Equal(v1alpha1.ApplyResultFailed))
+		Expect(status.Resources[1].Name).To(Equal("parallel-apply-missing-z"))
+		Expect(status.Resources[1].ApplyResult).To(Equal(v1alpha1.ApplyResultFailed))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool {
+			for i := range parallelApplyConfigMaps {
+				if configMapExists(parallelApplyConfigMapName(i)) {
+					return false
+				}
+			}
+			return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{}))
+		}).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+func parallelApplyConfigMapName(i int) string {
+	return fmt.Sprintf("parallel-apply-config-%d", i)
+}
+
+func missingNamespaceConfigMapManifest(name string) string {
+	return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + name + `
+  namespace: missing-namespace
+`
+}
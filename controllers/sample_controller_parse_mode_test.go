@@ -0,0 +1,138 @@
+package controllers_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	partiallyInvalidPath      = "./test/partially-invalid"
+	partiallyInvalidConfigMap = "partially-invalid-valid"
+	unparseableDocument       = "apiVersion: v1\nkind: ConfigMap\nmetadata: [invalid\n"
+)
+
+var _ = Describe("Sample CR is created with unparseable documents in Strict parse mode", Ordered, func() {
+	sampleCR := createSampleCR("strict-parse-sample", partiallyInvalidPath)
+	sampleCR.Spec.ParseMode = v1alpha1.ParseModeStrict
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should fail the installation pointing to the unparseable document", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+
+		condition := getInstallCondition(sampleCRKey)
+		Expect(condition.Reason).To(Equal(v1alpha1.ConditionReasonManifestParseError))
+		Expect(condition.Message).To(ContainSubstring("document 1 of"))
+		Expect(condition.Message).To(ContainSubstring("starting at line 9"))
+		Expect(configMapExists(partiallyInvalidConfigMap)).To(BeFalse())
+
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+	})
+})
+
+var _ = Describe("Sample CR is created with unparseable documents in Lenient parse mode", Ordered, func() {
+	sampleCR := createSampleCR("lenient-parse-sample", partiallyInvalidPath)
+	sampleCR.Spec.ParseMode = v1alpha1.ParseModeLenient
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should install all other resources and list the skipped document in Warning state", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateWarning, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(configMapExists(partiallyInvalidConfigMap)).To(BeTrue())
+
+		Expect(k8sClient.Get(ctx, sampleCRKey, sampleCR)).To(Succeed())
+		Expect(sampleCR.Status.SkippedDocuments).To(HaveLen(1))
+		Expect(sampleCR.Status.SkippedDocuments[0].Index).To(Equal(1))
+		Expect(sampleCR.Status.SkippedDocuments[0].Line).To(Equal(9))
+		Expect(sampleCR.Status.SkippedDocuments[0].Source).To(HaveSuffix("resources.yaml"))
+		Expect(getInstallCondition(sampleCRKey).Reason).To(Equal(v1alpha1.ConditionReasonDocumentsSkipped))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return configMapExists(partiallyInvalidConfigMap) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+	})
+})
+
+var _ = Describe("Sample CR skips documents of installed resources in Lenient parse mode", Ordered, func() {
+	var resourcePath string
+	sampleCR := createSampleCR("lenient-prune-sample", "")
+	sampleCR.Spec.ParseMode = v1alpha1.ParseModeLenient
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		resourcePath = createManifestDir(map[string]string{
+			"kept.yaml":    pruneConfigMapManifest("lenient-prune-kept", ""),
+			"skipped.yaml": pruneConfigMapManifest("lenient-prune-skipped", ""),
+		})
+		sampleCR.Spec.ResourceFilePath = resourcePath
+	})
+
+	It("should install all resources", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should neither prune nor forget resources while their documents are skipped", func() {
+		Expect(os.WriteFile(filepath.Join(resourcePath, "skipped.yaml"),
+			[]byte(unparseableDocument), 0o600)).To(Succeed())
+
+		Eventually(func() int { return len(getSampleStatus(sampleCRKey).SkippedDocuments) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(1))
+		Consistently(func() bool { return configMapExists("lenient-prune-skipped") }).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(getInventory(sampleCRKey)).To(ConsistOf(
+			pruneInventoryEntry("lenient-prune-kept"),
+			pruneInventoryEntry("lenient-prune-skipped"),
+		))
+	})
+
+	It("should prune resources once their documents are removed from the manifest", func() {
+		Expect(os.Remove(filepath.Join(resourcePath, "skipped.yaml"))).To(Succeed())
+
+		Eventually(func() bool { return configMapExists("lenient-prune-skipped") }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+		Eventually(getInventory).WithArguments(sampleCRKey).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(ConsistOf(pruneInventoryEntry("lenient-prune-kept")))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return configMapExists("lenient-prune-kept") }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+	})
+})
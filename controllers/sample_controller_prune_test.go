@@ -0,0 +1,118 @@
+package controllers_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sample CR prunes resources removed from the manifest", Ordered, func() {
+	var resourcePath string
+	sampleCR := createSampleCR("prune-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		resourcePath = createManifestDir(map[string]string{
+			"kept.yaml":      pruneConfigMapManifest("prune-kept", ""),
+			"removed.yaml":   pruneConfigMapManifest("prune-removed", ""),
+			"annotated.yaml": pruneConfigMapManifest("prune-annotated", "false"),
+		})
+		sampleCR.Spec.ResourceFilePath = resourcePath
+	})
+
+	It("should record all applied resources in the inventory", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(getInventory(sampleCRKey)).To(ConsistOf(
+			pruneInventoryEntry("prune-kept"),
+			pruneInventoryEntry("prune-removed"),
+			pruneInventoryEntry("prune-annotated"),
+		))
+	})
+
+	It("should prune removed resources which are not annotated", func() {
+		Expect(os.Remove(filepath.Join(resourcePath, "removed.yaml"))).To(Succeed())
+		Expect(os.Remove(filepath.Join(resourcePath, "annotated.yaml"))).To(Succeed())
+
+		Eventually(func() bool { return configMapExists("prune-removed") }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+		Expect(configMapExists("prune-kept")).To(BeTrue())
+		Expect(configMapExists("prune-annotated")).To(BeTrue())
+		Eventually(getInventory).WithArguments(sampleCRKey).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(ConsistOf(pruneInventoryEntry("prune-kept")))
+	})
+
+	It("should not prune resources when pruning is disabled", func() {
+		Expect(k8sClient.Get(ctx, sampleCRKey, sampleCR)).To(Succeed())
+		sampleCR.Spec.Prune = ptr.To(false)
+		Expect(k8sClient.Update(ctx, sampleCR)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(resourcePath, "kept.yaml"),
+			[]byte(pruneConfigMapManifest("prune-replacement", "")), 0o600)).To(Succeed())
+
+		Eventually(func() bool { return configMapExists("prune-replacement") }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(getInventory).WithArguments(sampleCRKey).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(ConsistOf(pruneInventoryEntry("prune-replacement")))
+		Expect(configMapExists("prune-kept")).To(BeTrue())
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return configMapExists("prune-replacement") }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+		for _, name := range []string{"prune-kept", "prune-annotated"} {
+			Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+			})).To(Succeed())
+		}
+	})
+})
+
+func pruneConfigMapManifest(name, pruneAnnotation string) string {
+	manifest := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + name + `
+  namespace: default
+`
+	if pruneAnnotation != "" {
+		manifest += `  annotations:
+    operator.kyma-project.io/prune: "` + pruneAnnotation + `"
+`
+	}
+	return manifest
+}
+
+func pruneInventoryEntry(name string) v1alpha1.InventoryEntry {
+	return v1alpha1.InventoryEntry{Version: "v1", Kind: "ConfigMap", Namespace: metav1.NamespaceDefault, Name: name}
+}
+
+func getInventory(sampleObjKey client.ObjectKey) []v1alpha1.InventoryEntry {
+	sampleCR := &v1alpha1.Sample{}
+	Expect(k8sClient.Get(ctx, sampleObjKey, sampleCR)).To(Succeed())
+	return sampleCR.Status.Inventory
+}
@@ -0,0 +1,157 @@
+package controllers_test
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	readinessDeploymentName = "readiness-deployment"
+	readinessPodName        = "readiness-pod"
+)
+
+var _ = Describe("Sample CR is created with a deployment which takes time to become available", Ordered, func() {
+	sampleCR := createSampleCR("readiness-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ` + readinessDeploymentName + `
+  namespace: default
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: readiness
+  template:
+    metadata:
+      labels:
+        app: readiness
+    spec:
+      containers:
+      - name: busybox
+        image: busybox:1.36
+`,
+		})
+	})
+
+	It("should stay in Processing state until the deployment is available", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateProcessing, InstallConditionStatus: metav1.ConditionUnknown, Err: nil}))
+		Eventually(func() string { return getInstallCondition(sampleCRKey).Reason }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(v1alpha1.ConditionReasonResourcesNotReady))
+		Expect(getInstallCondition(sampleCRKey).Message).To(ContainSubstring(readinessDeploymentName))
+		Consistently(getCRStatus(sampleCRKey)).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateProcessing, InstallConditionStatus: metav1.ConditionUnknown, Err: nil}))
+	})
+
+	It("should set state to Ready once the deployment is available", func() {
+		deployment := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: readinessDeploymentName},
+			deployment)).To(Succeed())
+		// there is no deployment controller in envtest, so the rollout is simulated
+		deployment.Status = appsv1.DeploymentStatus{
+			ObservedGeneration: deployment.GetGeneration(),
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			ReadyReplicas:      2,
+			AvailableReplicas:  2,
+		}
+		Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(getSampleStatus(sampleCRKey).ResourcesReady).To(Equal("1/1"))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+var _ = Describe("Sample CR is created with a pod which does not become ready in time", Ordered, func() {
+	sampleCR := createSampleCR("readiness-timeout-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		reconciler.ReadinessTimeout = 2 * time.Second
+		reconciler.ReadinessTimeoutState = v1alpha1.StateWarning
+		DeferCleanup(func() {
+			reconciler.ReadinessTimeout = 0
+			reconciler.ReadinessTimeoutState = v1alpha1.StateError
+		})
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: ` + readinessPodName + `
+  namespace: default
+spec:
+  containers:
+  - name: busybox
+    image: busybox:1.36
+`,
+		})
+	})
+
+	It("should set state to ReadinessTimeoutState after the readiness timeout", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateWarning, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+		condition := getInstallCondition(sampleCRKey)
+		Expect(condition.Reason).To(Equal(v1alpha1.ConditionReasonReadinessTimeout))
+		Expect(condition.Message).To(ContainSubstring(readinessPodName))
+	})
+
+	It("should set state to Ready once the pod is ready", func() {
+		pod := &corev1.Pod{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: readinessPodName},
+			pod)).To(Succeed())
+		// there is no kubelet in envtest, so the readiness of the pod is simulated
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, readinessPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
@@ -18,25 +18,30 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io/fs"
-	"os"
-	"path/filepath"
+	"net/http"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/scheme"
 
-	"github.com/go-logr/logr"
-	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/lru"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/kyma-project/template-operator/api/v1alpha1"
 
@@ -52,11 +57,73 @@ type SampleReconciler struct {
 	record.EventRecorder
 	FinalState         v1alpha1.State
 	FinalDeletionState v1alpha1.State
+	// ManifestCacheDir is the directory remote manifests are cached in, keyed by their digest,
+	// and git repositories are checked out to. Without a ManifestCacheDir, remote manifests
+	// and git repositories are fetched entirely with every reconciliation.
+	ManifestCacheDir string
+	// GitFileRoot is the directory file:// git remotes are served from in-process.
+	// Without a GitFileRoot, file:// remotes are rejected. The file:// transport of go-git is replaced
+	// for the whole process in SetupWithManager, so only one GitFileRoot is supported per process.
+	GitFileRoot string
+	// HTTPClient fetches manifests from URLs, defaults to a client with a timeout of 30 seconds.
+	HTTPClient *http.Client
+	// HealthRules define the health of applied resources for all Samples, see v1alpha1.SampleSpec.HealthRules.
+	HealthRules []v1alpha1.HealthRule
+	// ReadinessTimeout is the time the applied resources have to become ready, before the reconciled resource
+	// is set to ReadinessTimeoutState. With a ReadinessTimeout of 0, the resources are waited for indefinitely.
+	ReadinessTimeout      time.Duration
+	ReadinessTimeoutState v1alpha1.State
+	// HookTimeout is the time the hooks of a phase have to complete, before they are considered failed.
+	// With a HookTimeout of 0, hooks are waited for indefinitely.
+	HookTimeout time.Duration
+	// DeletionBlockingKinds are the kinds of resources created by users of the module, such as ThirdParty.
+	// Samples are not deleted while resources of these kinds exist, which are not part of their manifest.
+	DeletionBlockingKinds []schema.GroupVersionKind
+	// MaxConcurrentReconciles is the maximum number of Samples reconciled at the same time, defaults to 1.
+	MaxConcurrentReconciles int
+	// MaxConcurrentApplies is the maximum number of resources of a Sample applied at the same time.
+	// Only resources of the same sync wave and kind phase are applied in parallel, see applyGroupsOf.
+	MaxConcurrentApplies int
+	// ResyncInterval is the interval Samples in Ready or Warning state are reconciled in, to fetch changes of
+	// remote manifests and to correct changes of applied resources which were missed by their watches.
+	// With a ResyncInterval of 0, Samples are only reconciled on changes of themselves or their resources.
+	ResyncInterval time.Duration
+
+	// apiReader reads uncached, for decisions which must not be based on a stale cache
+	apiReader       client.Reader
+	watches         *resourceWatches
+	appliedVersions appliedVersions
+	gitFetches      gitFetches
+	manifestFiles   *manifestFileCache
+	manifestPaths   manifestPaths
+	manifestWatcher *manifestWatcher
+	// operatorHealthRules are the compiled HealthRules, healthRulePrograms the programs of spec.healthRules
+	operatorHealthRules healthRules
+	healthRulePrograms  *lru.Cache
 }
 
 type ManifestResources struct {
 	Items []*unstructured.Unstructured
-	Blobs [][]byte
+	Blobs []ManifestBlob
+	// Source describes the manifest source the resources were loaded from.
+	Source v1alpha1.SourceStatus
+}
+
+// ManifestBlob is a document of a manifest which could not be parsed into a resource.
+type ManifestBlob struct {
+	// Source is the file or template the document originates from.
+	Source string
+	// Index of the document in its source, starting at 0.
+	Index int
+	// Line the document starts at in its source, starting at 1.
+	Line int
+	Data []byte
+	Err  error
+}
+
+func (m *ManifestResources) merge(other *ManifestResources) {
+	m.Items = append(m.Items, other.Items...)
+	m.Blobs = append(m.Blobs, other.Blobs...)
 }
 
 var (
@@ -68,7 +135,7 @@ var (
 )
 
 func init() { //nolint:gochecknoinits
-	SchemeBuilder.Register(&v1alpha1.Sample{}, &v1alpha1.SampleList{})
+	SchemeBuilder.Register(&v1alpha1.Sample{}, &v1alpha1.SampleList{}, &v1alpha1.Managed{}, &v1alpha1.ManagedList{})
 }
 
 // +kubebuilder:rbac:groups=operator.kyma-project.io,resources=samples,verbs=get;list;watch;create;update;patch;delete
@@ -76,17 +143,56 @@ func init() { //nolint:gochecknoinits
 // +kubebuilder:rbac:groups=operator.kyma-project.io,resources=samples/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch;get;list;watch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups="",resources=configmaps,verbs=create;patch;delete
-// +kubebuilder:rbac:groups="apps",resources=deployments,verbs=create;patch;delete
-// +kubebuilder:rbac:groups="apps",resources=statefulsets,verbs=create;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="apiextensions.k8s.io",resources=customresourcedefinitions,verbs=get;list;watch;create;patch;delete
+// +kubebuilder:rbac:groups="apps",resources=deployments,verbs=get;list;watch;create;patch;delete
+// +kubebuilder:rbac:groups="apps",resources=statefulsets,verbs=get;list;watch;create;patch;delete
+// +kubebuilder:rbac:groups=operator.kyma-project.io,resources=thirdparties,verbs=get;list;watch
+// +kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;list;watch;create;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;patch;delete
+// +kubebuilder:rbac:groups="storage.k8s.io",resources=storageclasses,verbs=get;list;watch
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *SampleReconciler) SetupWithManager(mgr ctrl.Manager, rateLimiter RateLimiter) error {
 	r.Config = mgr.GetConfig()
+	r.apiReader = mgr.GetAPIReader()
 
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.Sample{},
+		referencedObjectsIndex, indexReferencedObjects); err != nil {
+		return fmt.Errorf("failed to index referenced objects of samples: %w", err)
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.Sample{},
+		sampleUIDIndex, indexSampleUID); err != nil {
+		return fmt.Errorf("failed to index uids of samples: %w", err)
+	}
+
+	operatorHealthRules, err := compileHealthRules(r.HealthRules, "health rules of the operator", nil)
+	if err != nil {
+		return err
+	}
+	r.operatorHealthRules = operatorHealthRules
+	r.healthRulePrograms = lru.New(healthRuleProgramCacheSize)
+
+	serveLocalRepositories(r.GitFileRoot)
+	r.manifestFiles = newManifestFileCache()
+	manifestWatcher, err := newManifestWatcher()
+	if err != nil {
+		return err
+	}
+	r.manifestWatcher = manifestWatcher
+
+	// applied resources are watched dynamically once their kinds are known, see watchAppliedResources
+	sampleController, err := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.Sample{}).
+		Owns(&v1alpha1.Managed{}).
+		Watches(&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForReferencedObject(v1alpha1.ReferenceKindConfigMap))).
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForReferencedObject(v1alpha1.ReferenceKindSecret))).
+		WatchesRawSource(source.Func(r.startManifestWatcher)).
 		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
 			RateLimiter: TemplateRateLimiter(
 				rateLimiter.BaseDelay,
 				rateLimiter.FailureMaxDelay,
@@ -94,7 +200,12 @@ func (r *SampleReconciler) SetupWithManager(mgr ctrl.Manager, rateLimiter RateLi
 				rateLimiter.Burst,
 			),
 		}).
-		Complete(r)
+		Build(r)
+	if err != nil {
+		return fmt.Errorf("failed to build controller: %w", err)
+	}
+	r.watches = newResourceWatches(sampleController, mgr.GetCache(), r.requestsForAppliedObject)
+	return nil
 }
 
 // Reconcile is the entry point from the controller-runtime framework.
@@ -116,8 +227,14 @@ func (r *SampleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	status := getStatusFromSample(&objectInstance)
 
 	// set state to FinalDeletionState (default is Deleting) if not set for an object with deletion timestamp
-	if !objectInstance.GetDeletionTimestamp().IsZero() && status.State != r.FinalDeletionState {
-		return ctrl.Result{}, r.setStatusForObjectInstance(ctx, &objectInstance, status.WithState(r.FinalDeletionState))
+	if !objectInstance.GetDeletionTimestamp().IsZero() && status.State != r.FinalDeletionState &&
+		!isDeletionBlocked(&status) {
+		if err := r.setStatusForObjectInstance(ctx, &objectInstance, status.WithState(r.FinalDeletionState)); err != nil {
+			return ctrl.Result{}, err
+		}
+		// the event is only recorded on the transition, not with each reconciliation of the deletion
+		r.Event(&objectInstance, "Normal", "Deleting", "resource deleting")
+		return ctrl.Result{}, nil
 	}
 
 	if objectInstance.GetDeletionTimestamp().IsZero() {
@@ -127,17 +244,25 @@ func (r *SampleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	// a deletion which is blocked by resources of users is continued in Warning state
+	if !objectInstance.GetDeletionTimestamp().IsZero() && isDeletionBlocked(&status) {
+		return ctrl.Result{RequeueAfter: requeueInterval}, r.HandleDeletingState(ctx, &objectInstance)
+	}
+
 	switch status.State {
 	case "":
 		return ctrl.Result{}, r.HandleInitialState(ctx, &objectInstance)
 	case v1alpha1.StateProcessing:
-		return ctrl.Result{Requeue: true}, r.HandleProcessingState(ctx, &objectInstance)
+		// resources are polled for readiness, so the rate limiter does not delay the transition to Ready
+		return ctrl.Result{RequeueAfter: requeueInterval}, r.HandleProcessingState(ctx, &objectInstance)
 	case v1alpha1.StateDeleting:
-		return ctrl.Result{Requeue: true}, r.HandleDeletingState(ctx, &objectInstance)
+		// resources are polled until they are gone, so the rate limiter does not delay the removal of the finalizer
+		return ctrl.Result{RequeueAfter: requeueInterval}, r.HandleDeletingState(ctx, &objectInstance)
 	case v1alpha1.StateError:
 		return ctrl.Result{Requeue: true}, r.HandleErrorState(ctx, &objectInstance)
 	case v1alpha1.StateReady, v1alpha1.StateWarning:
-		return ctrl.Result{RequeueAfter: requeueInterval}, r.HandleReadyState(ctx, &objectInstance)
+		// changes of applied resources are watched, resyncs are a fallback for missed events and remote manifests
+		return ctrl.Result{RequeueAfter: r.ResyncInterval}, r.HandleReadyState(ctx, &objectInstance)
 	}
 
 	return ctrl.Result{}, nil
@@ -156,27 +281,27 @@ func (r *SampleReconciler) HandleInitialState(ctx context.Context, objectInstanc
 // Based on the processing either a success or failure state is set on the reconciled resource.
 func (r *SampleReconciler) HandleProcessingState(ctx context.Context, objectInstance *v1alpha1.Sample) error {
 	status := getStatusFromSample(objectInstance)
-	if err := r.processResources(ctx, objectInstance); err != nil {
+	if err := r.processResources(ctx, objectInstance, &status); err != nil {
 		// stay in Processing state if FinalDeletionState is set to Processing
 		if !objectInstance.GetDeletionTimestamp().IsZero() && r.FinalDeletionState == v1alpha1.StateProcessing {
 			return nil
 		}
 
-		r.Event(objectInstance, "Warning", "ResourcesInstall", err.Error())
+		reason := installConditionReason(err)
+		r.Event(objectInstance, "Warning", reason, err.Error())
 		return r.setStatusForObjectInstance(ctx, objectInstance, status.
 			WithState(v1alpha1.StateError).
-			WithInstallConditionStatus(metav1.ConditionFalse, objectInstance.GetGeneration()))
+			WithInstallConditionStatus(metav1.ConditionFalse, objectInstance.GetGeneration()).
+			WithInstallConditionReason(reason, err.Error()))
 	}
-	// set eventual state to Ready - if no errors were found
-	return r.setStatusForObjectInstance(ctx, objectInstance, status.
-		WithState(r.FinalState).
-		WithInstallConditionStatus(metav1.ConditionTrue, objectInstance.GetGeneration()))
+	// set eventual state to Ready - if no errors were found and all resources are ready
+	return r.setReadinessStatus(ctx, objectInstance, &status)
 }
 
 // HandleErrorState handles error recovery for the reconciled resource.
 func (r *SampleReconciler) HandleErrorState(ctx context.Context, objectInstance *v1alpha1.Sample) error {
 	status := getStatusFromSample(objectInstance)
-	if err := r.processResources(ctx, objectInstance); err != nil {
+	if err := r.processResources(ctx, objectInstance, &status); err != nil {
 		return err
 	}
 
@@ -184,67 +309,170 @@ func (r *SampleReconciler) HandleErrorState(ctx context.Context, objectInstance
 	if !objectInstance.GetDeletionTimestamp().IsZero() && r.FinalDeletionState == v1alpha1.StateError {
 		return nil
 	}
-	// set eventual state to Ready - if no errors were found
-	return r.setStatusForObjectInstance(ctx, objectInstance, status.
-		WithState(r.FinalState).
-		WithInstallConditionStatus(metav1.ConditionTrue, objectInstance.GetGeneration()))
+	// set eventual state to Ready - if no errors were found and all resources are ready
+	return r.setReadinessStatus(ctx, objectInstance, &status)
 }
 
 // HandleDeletingState processed the deletion on the reconciled resource.
 // Once the deletion if processed the relevant finalizers (if applied) are removed.
 func (r *SampleReconciler) HandleDeletingState(ctx context.Context, objectInstance *v1alpha1.Sample) error {
-	r.Event(objectInstance, "Normal", "Deleting", "resource deleting")
 	logger := log.FromContext(ctx)
 
 	status := getStatusFromSample(objectInstance)
 
-	resourceObjs, err := getResourcesFromLocalPath(objectInstance.Spec.ResourceFilePath, logger)
-	if err != nil && controllerutil.RemoveFinalizer(objectInstance, finalizer) {
-		// if error is encountered simply remove the finalizer and delete the reconciled resource
-		return r.Client.Update(ctx, objectInstance)
+	resourceObjs, err := r.getManifestResources(ctx, objectInstance)
+	if err != nil {
+		// if the manifest cannot be resolved, the resources of the inventory are deleted
+		// before the finalizer is removed
+		logger.Error(err, "error locating manifest of resources, deleting resources of the inventory")
+		r.Event(objectInstance, "Warning", installConditionReason(err), err.Error())
+		inventoryObjs, err := r.inventoryResources(ctx, status.Inventory)
+		if err != nil {
+			logger.Error(err, "error reading resources of the inventory")
+			return err
+		}
+		if blocked, err := r.checkDeletionBlocked(ctx, objectInstance, &status, inventoryObjs); err != nil || blocked {
+			return err
+		}
+		remaining, err := r.deleteResources(ctx, objectInstance, inventoryObjs)
+		if err != nil {
+			logger.Error(err, "error during uninstallation of resources of the inventory")
+			return err
+		}
+		if len(remaining) > 0 {
+			status.WithInstallConditionReason(v1alpha1.ConditionReasonResourcesDeleting,
+				"waiting for resources to be deleted: "+describeObjects(remaining))
+			return r.setChangedStatus(ctx, objectInstance, &status)
+		}
+		r.cleanupManifestCache(ctx, objectInstance)
+		r.appliedVersions.forget(client.ObjectKeyFromObject(objectInstance))
+		if controllerutil.RemoveFinalizer(objectInstance, finalizer) {
+			return r.Client.Update(ctx, objectInstance)
+		}
+		return nil
+	}
+	// resources of users need to be removed before the module is uninstalled
+	if blocked, err := r.checkDeletionBlocked(ctx, objectInstance, &status, resourceObjs.Items); err != nil || blocked {
+		return err
+	}
+
+	// hooks only run for installed resources, pre-delete hooks before the resources are deleted
+	resources, hooks := splitHooks(resourceObjs.Items)
+	installed := len(status.Inventory) > 0
+	if installed {
+		if done, err := r.runHooks(ctx, objectInstance, &status, hookTypePreDelete, hooks); err != nil || !done {
+			return r.setHooksDeletingStatus(ctx, objectInstance, &status, err)
+		}
 	}
 	r.Event(objectInstance, "Normal", "ResourcesDelete", "deleting resources")
 
-	// the resources to be installed are unstructured,
-	// so please make sure the types are available on the target cluster
-	for _, obj := range resourceObjs.Items {
-		if err = r.Client.Delete(ctx, obj); err != nil && !errors2.IsNotFound(err) {
-			// stay in Deleting state if FinalDeletionState is set to Deleting
-			if !objectInstance.GetDeletionTimestamp().IsZero() && r.FinalDeletionState == v1alpha1.StateDeleting {
-				return nil
-			}
+	// the resources to be deleted are unstructured, they are deleted in the reverse order of their kinds,
+	// hooks which do not run after the deletion are deleted with them
+	remaining, err := r.deleteResources(ctx, objectInstance,
+		append(resources, hooksExcept(hooks, hookTypePostDelete)...))
+	if err != nil {
+		// stay in Deleting state if FinalDeletionState is set to Deleting
+		if !objectInstance.GetDeletionTimestamp().IsZero() && r.FinalDeletionState == v1alpha1.StateDeleting {
+			return nil
+		}
 
-			logger.Error(err, "error during uninstallation of resources")
-			r.Event(objectInstance, "Warning", "ResourcesDelete", "deleting resources error")
-			return r.setStatusForObjectInstance(ctx, objectInstance, status.
-				WithState(v1alpha1.StateError).
-				WithInstallConditionStatus(metav1.ConditionFalse, objectInstance.GetGeneration()))
+		logger.Error(err, "error during uninstallation of resources")
+		r.Event(objectInstance, "Warning", "ResourcesDelete", "deleting resources error")
+		return r.setStatusForObjectInstance(ctx, objectInstance, status.
+			WithState(v1alpha1.StateError).
+			WithInstallConditionStatus(metav1.ConditionFalse, objectInstance.GetGeneration()))
+	}
+
+	// keep the finalizer until all resources are gone
+	if len(remaining) > 0 {
+		status.SyncWave = ptr.To(syncWaveOf(remaining[0]))
+		status.WithInstallConditionReason(v1alpha1.ConditionReasonResourcesDeleting,
+			"waiting for resources to be deleted: "+describeObjects(remaining))
+		return r.setChangedStatus(ctx, objectInstance, &status)
+	}
+	if installed {
+		if done, err := r.runHooks(ctx, objectInstance, &status, hookTypePostDelete, hooks); err != nil || !done {
+			return r.setHooksDeletingStatus(ctx, objectInstance, &status, err)
 		}
 	}
 
-	// if resources are ready to be deleted, remove finalizer
+	// if resources are deleted, remove finalizer
+	if err = r.deleteManaged(ctx, objectInstance); err != nil {
+		logger.Error(err, "error during deletion of managed resource")
+		return err
+	}
+	r.cleanupManifestCache(ctx, objectInstance)
+	r.appliedVersions.forget(client.ObjectKeyFromObject(objectInstance))
 	if controllerutil.RemoveFinalizer(objectInstance, finalizer) {
 		return r.Client.Update(ctx, objectInstance)
 	}
 	return nil
 }
 
+// setHooksDeletingStatus keeps the reconciled resource in deletion while its delete hooks are running,
+// or after they failed with err until they run again for a new generation.
+func (r *SampleReconciler) setHooksDeletingStatus(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus, err error,
+) error {
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error running hooks")
+		status.WithInstallConditionReason(installConditionReason(err), err.Error())
+	} else {
+		status.WithInstallConditionReason(v1alpha1.ConditionReasonHooksRunning,
+			runningHooks(status, objectInstance.GetGeneration()))
+	}
+	return r.setChangedStatus(ctx, objectInstance, status)
+}
+
+// setChangedStatus sets status on the reconciled resource if it changed, so polling does not cause writes.
+func (r *SampleReconciler) setChangedStatus(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus,
+) error {
+	if equality.Semantic.DeepEqual(*status, objectInstance.Status) {
+		return nil
+	}
+	return r.setStatusForObjectInstance(ctx, objectInstance, status)
+}
+
 // HandleReadyState checks for the consistency of reconciled resource, by verifying the underlying resources.
 func (r *SampleReconciler) HandleReadyState(ctx context.Context, objectInstance *v1alpha1.Sample) error {
 	status := getStatusFromSample(objectInstance)
-	if err := r.processResources(ctx, objectInstance); err != nil {
+	if err := r.processResources(ctx, objectInstance, &status); err != nil {
 		// stay in Ready/Warning state if FinalDeletionState is set to Ready/Warning
 		if !objectInstance.GetDeletionTimestamp().IsZero() &&
 			(r.FinalDeletionState == v1alpha1.StateReady || r.FinalDeletionState == v1alpha1.StateWarning) {
 			return nil
 		}
 
-		r.Event(objectInstance, "Warning", "ResourcesInstall", err.Error())
+		reason := installConditionReason(err)
+		r.Event(objectInstance, "Warning", reason, err.Error())
 		return r.setStatusForObjectInstance(ctx, objectInstance, status.
 			WithState(v1alpha1.StateError).
-			WithInstallConditionStatus(metav1.ConditionFalse, objectInstance.GetGeneration()))
+			WithInstallConditionStatus(metav1.ConditionFalse, objectInstance.GetGeneration()).
+			WithInstallConditionReason(reason, err.Error()))
 	}
-	return nil
+
+	if !objectInstance.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+	// switch between Ready, Warning and Processing state if the result of the installation changed
+	return r.setReadinessStatus(ctx, objectInstance, &status)
+}
+
+// withInstalledStatus sets the state and Installation condition of a successfully installed resource.
+// If documents of the manifest were skipped, the state is set to Warning, otherwise to FinalState.
+func (r *SampleReconciler) withInstalledStatus(status *v1alpha1.SampleStatus,
+	objGeneration int64,
+) *v1alpha1.SampleStatus {
+	status.WithInstallConditionStatus(metav1.ConditionTrue, objGeneration)
+	if len(status.SkippedDocuments) == 0 {
+		return status.WithState(r.FinalState)
+	}
+	return status.
+		WithState(v1alpha1.StateWarning).
+		WithInstallConditionReason(v1alpha1.ConditionReasonDocumentsSkipped,
+			"installation is ready, but documents of the manifest could not be parsed and were skipped, "+
+				"see status.skippedDocuments")
 }
 
 func (r *SampleReconciler) setStatusForObjectInstance(ctx context.Context, objectInstance *v1alpha1.Sample,
@@ -262,70 +490,202 @@ func (r *SampleReconciler) setStatusForObjectInstance(ctx context.Context, objec
 	return nil
 }
 
-func (r *SampleReconciler) processResources(ctx context.Context, objectInstance *v1alpha1.Sample) error {
+func (r *SampleReconciler) processResources(ctx context.Context, objectInstance *v1alpha1.Sample,
+	status *v1alpha1.SampleStatus,
+) error {
 	logger := log.FromContext(ctx)
 
-	resourceObjs, err := getResourcesFromLocalPath(objectInstance.Spec.ResourceFilePath, logger)
+	resourceObjs, err := r.getManifestResources(ctx, objectInstance)
 	if err != nil {
 		logger.Error(err, "error locating manifest of resources")
 		return err
 	}
 
-	r.Event(objectInstance, "Normal", "ResourcesInstall", "installing resources")
+	status.SkippedDocuments, err = getSkippedDocuments(resourceObjs.Blobs, objectInstance.Spec.ParseMode)
+	if err != nil {
+		logger.Error(err, "error parsing manifest of resources")
+		return err
+	}
+	source := resourceObjs.Source
+	status.Source = &source
 
-	// the resources to be installed are unstructured,
-	// so please make sure the types are available on the target cluster
-	for _, obj := range resourceObjs.Items {
-		if err = r.ssa(ctx, obj); err != nil && !errors2.IsAlreadyExists(err) {
-			logger.Error(err, "error during installation of resources")
-			return err
-		}
+	rules, err := r.healthRulesFor(objectInstance)
+	if err != nil {
+		logger.Error(err, "error compiling health rules")
+		return err
 	}
-	return nil
-}
 
-func getStatusFromSample(objectInstance *v1alpha1.Sample) v1alpha1.SampleStatus {
-	return objectInstance.Status
-}
+	if err = validateSyncWaves(resourceObjs.Items); err != nil {
+		logger.Error(err, "error reading sync waves of resources")
+		return err
+	}
+
+	resourceItems, hooks := splitHooks(resourceObjs.Items)
+	if err = validateHooks(hooks); err != nil {
+		logger.Error(err, "error reading hooks of resources")
+		return err
+	}
+	if err = checkInventorySize(len(newInventory(resourceItems))); err != nil {
+		logger.Error(err, "error checking the number of resources")
+		return err
+	}
+	hash, err := manifestHashOf(objectInstance, resourceObjs.Items)
+	if err != nil {
+		logger.Error(err, "error hashing manifest of resources")
+		return err
+	}
+
+	// pre-install hooks run before the first installation, pre-upgrade hooks before a new generation
+	// or a changed manifest is applied
+	preHooksDone := true
+	switch {
+	case status.InstalledGeneration == 0 && len(status.Inventory) == 0:
+		preHooksDone, err = r.runHooks(ctx, objectInstance, status, hookTypePreInstall, hooks)
+	case status.InstalledGeneration != objectInstance.GetGeneration() || status.ManifestHash != hash:
+		if status.PreUpgradeHooksHash != hash {
+			// the hooks of a previous upgrade of the same generation are not reused
+			meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ConditionTypePreUpgradeHooks)
+			status.PreUpgradeHooksHash = hash
+		}
+		preHooksDone, err = r.runHooks(ctx, objectInstance, status, hookTypePreUpgrade, hooks)
+	}
+	if err != nil {
+		logger.Error(err, "error running hooks")
+		return err
+	}
+	if !preHooksDone {
+		return nil
+	}
 
-// getResourcesFromLocalPath returns resources from the dirPath in unstructured format.
-// Only one file in .yaml or .yml format should be present in the target directory.
-func getResourcesFromLocalPath(dirPath string, logger logr.Logger) (*ManifestResources, error) {
-	dirEntries := make([]fs.DirEntry, 0)
-	err := filepath.WalkDir(dirPath, func(path string, info fs.DirEntry, err error) error {
-		// initial error
+	// resources are not applied again if neither the manifest nor the applied resources changed
+	postInstall := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionTypePostInstallHooks)
+	postInstallDone := len(hooksOfType(hooks, hookTypePostInstall)) == 0 ||
+		(postInstall != nil && postInstall.Status == metav1.ConditionTrue)
+	change := installationChanged
+	if postInstallDone {
+		change = r.installationChangeOf(ctx, objectInstance, status, hash)
+	}
+	switch change {
+	case installationUnchanged:
+		resourceApplies.WithLabelValues(applyResultSkipped).Add(float64(len(resourceItems)))
+		return nil
+	case installationStatusChanged:
+		sortByApplyOrder(resourceItems)
+		refreshed, err := r.refreshResourceStatuses(ctx, objectInstance, status, resourceItems, rules)
 		if err != nil {
+			logger.Error(err, "error evaluating health of resources")
 			return err
 		}
-		if !info.IsDir() {
+		if refreshed {
+			resourceApplies.WithLabelValues(applyResultSkipped).Add(float64(len(resourceItems)))
 			return nil
 		}
-		dirEntries, err = os.ReadDir(dirPath)
+	}
+
+	r.Event(objectInstance, "Normal", "ResourcesInstall", "installing resources")
+
+	// the resources to be installed are unstructured, they are applied wave by wave and in the order
+	// of their kinds within a wave, so namespaces and definitions of custom resources exist
+	// before the resources depending on them
+	sortByApplyOrder(resourceItems)
+	resources := make([]v1alpha1.ResourceStatus, 0, len(resourceItems))
+	applyErrs := make([]error, 0)
+	status.SyncWave = nil
+	waitingForWave := false
+	for _, wave := range syncWavesOf(resourceItems) {
+		// later waves are only applied once the current wave is healthy
+		if waitingForWave {
+			for _, obj := range wave.objs {
+				resources = append(resources, pendingResourceStatus(obj, *status.SyncWave))
+			}
+			continue
+		}
+
+		status.SyncWave = ptr.To(wave.number)
+		waveResources := make([]v1alpha1.ResourceStatus, 0, len(wave.objs))
+		awaitedResources := make([]v1alpha1.ResourceStatus, 0, len(wave.objs))
+		waitingForDefinitions := false
+		// resources of the same kind phase are applied in parallel, errors are collected for all resources
+		for _, group := range applyGroupsOf(wave.objs) {
+			// later phases may contain instances of applied definitions, they are applied once these are established
+			if waitingForDefinitions {
+				for _, obj := range group {
+					waveResources = append(waveResources, pendingDefinitionStatus(obj))
+					awaitedResources = append(awaitedResources, pendingDefinitionStatus(obj))
+				}
+				continue
+			}
+			for i, err := range r.applyInParallel(ctx, objectInstance, group) {
+				if err != nil {
+					applyErrs = append(applyErrs,
+						fmt.Errorf("%s could not be applied: %w", inventoryEntryOf(group[i]), err))
+				}
+				resourceStatus := newResourceStatus(group[i], err, rules)
+				if err == nil && isCustomResourceDefinition(group[i]) {
+					if established, message := r.isEstablished(ctx, group[i]); !established {
+						resourceStatus.Health, resourceStatus.Message = v1alpha1.ResourceHealthProgressing, message
+						waitingForDefinitions = true
+					}
+				}
+				// claims waiting for their first consumer do not hold back the later waves containing it
+				awaited := true
+				if err == nil && resourceStatus.Health != v1alpha1.ResourceHealthHealthy &&
+					r.isWaitingForFirstConsumer(ctx, group[i]) {
+					resourceStatus.Health = v1alpha1.ResourceHealthProgressing
+					resourceStatus.Message = "waiting for the first consumer of the claim to be scheduled"
+					awaited = false
+				}
+				waveResources = append(waveResources, resourceStatus)
+				if awaited {
+					awaitedResources = append(awaitedResources, resourceStatus)
+				}
+			}
+		}
+		resources = append(resources, waveResources...)
+		r.watchAppliedResources(ctx, wave.objs)
+		waitingForWave = !isSyncWaveHealthy(awaitedResources)
+	}
+	setResourceStatuses(status, resources)
+	if err = errors.Join(applyErrs...); err != nil {
+		logger.Error(err, "error during installation of resources")
 		return err
-	})
-	if err != nil {
-		return nil, err
 	}
 
-	childCount := len(dirEntries)
-	if childCount == 0 {
-		logger.V(debugLogLevel).Info(fmt.Sprintf("no yaml file found at file path %s", dirPath))
-		return nil, nil
-	} else if childCount > 1 {
-		logger.V(debugLogLevel).Info(fmt.Sprintf("more than one yaml file found at file path %s", dirPath))
-		return nil, nil
+	inventory := newInventory(resourceItems)
+	if len(status.SkippedDocuments) > 0 {
+		inventory = withRetainedEntries(status.Inventory, inventory)
+		if err = checkInventorySize(len(inventory)); err != nil {
+			logger.Error(err, "error checking the number of retained resources")
+			return err
+		}
+	}
+	if err = r.pruneResources(ctx, objectInstance, status.Inventory, inventory); err != nil {
+		logger.Error(err, "error during pruning of resources")
+		return err
 	}
-	file := dirEntries[0]
-	allowedExtns := sets.NewString(".yaml", ".yml")
-	if !allowedExtns.Has(filepath.Ext(file.Name())) {
-		return nil, nil
+	status.Inventory = inventory
+	status.InstalledGeneration = objectInstance.GetGeneration()
+	status.ManifestHash = hash
+	r.appliedVersions.set(client.ObjectKeyFromObject(objectInstance), resourceItems)
+	if err = r.syncManaged(ctx, objectInstance, inventory); err != nil {
+		logger.Error(err, "error during sync of managed resource")
+		return err
 	}
 
-	fileBytes, err := os.ReadFile(filepath.Join(dirPath, file.Name()))
-	if err != nil {
-		return nil, fmt.Errorf("yaml file could not be read %s in dir %s: %w", file.Name(), dirPath, err)
+	// post-install hooks run once, after all resources of the first installation are ready
+	if waitingForWave || describeResources(resources, v1alpha1.ResourceHealthProgressing,
+		v1alpha1.ResourceHealthDegraded) != "" || postInstallDone {
+		return nil
+	}
+	if _, err = r.runHooks(ctx, objectInstance, status, hookTypePostInstall, hooks); err != nil {
+		logger.Error(err, "error running hooks")
+		return err
 	}
-	return parseManifestStringToObjects(string(fileBytes))
+	return nil
+}
+
+func getStatusFromSample(objectInstance *v1alpha1.Sample) v1alpha1.SampleStatus {
+	return *objectInstance.Status.DeepCopy()
 }
 
 // ssaStatus patches status using SSA on the passed object.
@@ -28,15 +28,15 @@ var _ = Describe("Sample CR is created with the correct resource path", Ordered,
 	It("should create SampleCR and resources", func() {
 		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
 
-		Eventually(getCRStatus(sampleCRKey)).
+		Eventually(getPod(podNs, podName)).
 			WithTimeout(30 * time.Second).
 			WithPolling(500 * time.Millisecond).
-			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+			Should(BeTrue())
 
-		Eventually(getPod(podNs, podName)).
+		Eventually(getCRStatus(sampleCRKey)).
 			WithTimeout(30 * time.Second).
 			WithPolling(500 * time.Millisecond).
-			Should(BeTrue())
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
 	})
 
 	It("should set state to Warning when deleted after setting FinalDeletionState", func() {
@@ -61,7 +61,11 @@ var _ = Describe("Sample CR is created with the correct resource path", Ordered,
 
 	It("should delete when FinalDeletionState set to Deleting", func() {
 		reconciler.FinalDeletionState = v1alpha1.StateDeleting
-		Eventually(checkDeleted(sampleCRKey)).
+		Eventually(finalizeNamespace(podNs)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(checkDeleted(sampleCRKey, podNs, podName)).
 			WithTimeout(30 * time.Second).
 			WithPolling(500 * time.Millisecond).
 			Should(BeTrue())
@@ -144,7 +148,7 @@ func getCRStatus(sampleObjKey client.ObjectKey) func(g Gomega) CRStatus {
 	}
 }
 
-func checkDeleted(sampleObjKey client.ObjectKey) func(g Gomega) bool {
+func checkDeleted(sampleObjKey client.ObjectKey, podNs, podName string) func(g Gomega) bool {
 	return func(g Gomega) bool {
 		clientSet, err := kubernetes.NewForConfig(reconciler.Config)
 		g.Expect(err).ToNot(HaveOccurred())
@@ -160,3 +164,28 @@ func checkDeleted(sampleObjKey client.ObjectKey) func(g Gomega) bool {
 		return false
 	}
 }
+
+// finalizeNamespace removes the namespace once its deletion started. Because there are no controllers
+// finalizing namespaces, they would be terminating forever otherwise.
+func finalizeNamespace(name string) func(g Gomega) bool {
+	return func(g Gomega) bool {
+		clientSet, err := kubernetes.NewForConfig(reconciler.Config)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		namespace, err := clientSet.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return true
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		if namespace.GetDeletionTimestamp().IsZero() {
+			return false
+		}
+
+		namespace.Spec.Finalizers = nil
+		_, err = clientSet.CoreV1().Namespaces().Finalize(ctx, namespace, metav1.UpdateOptions{})
+		g.Expect(err).ToNot(HaveOccurred())
+		err = clientSet.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
+		g.Expect(client.IgnoreNotFound(err)).ToNot(HaveOccurred())
+		return false
+	}
+}
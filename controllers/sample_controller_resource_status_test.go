@@ -0,0 +1,82 @@
+package controllers_test
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const resourceStatusPodName = "busybox-resource-status-pod"
+
+var _ = Describe("Sample CR reports the apply result and health of each resource", Ordered, func() {
+	sampleCR := createSampleCR("resource-status-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"a-configmap.yaml": pruneConfigMapManifest("resource-status-config", ""),
+			"b-pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: ` + resourceStatusPodName + `
+  namespace: default
+spec:
+  containers:
+  - name: busybox
+    image: busybox:1.36
+`,
+			"c-missing-namespace.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: resource-status-missing
+  namespace: missing-namespace
+`,
+		})
+	})
+
+	It("should list the resource which failed to apply first", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+		Expect(getInstallCondition(sampleCRKey).Message).To(ContainSubstring("resource-status-missing"))
+
+		status := getSampleStatus(sampleCRKey)
+		Expect(status.ResourcesReady).To(Equal("1/3"))
+		Expect(status.Resources).To(HaveLen(3))
+		Expect(status.Resources[0].Name).To(Equal("resource-status-missing"))
+		Expect(status.Resources[0].ApplyResult).To(Equal(v1alpha1.ApplyResultFailed))
+		Expect(status.Resources[0].Message).NotTo(BeEmpty())
+		Expect(status.Resources[1].Name).To(Equal(resourceStatusPodName))
+		Expect(status.Resources[1].ApplyResult).To(Equal(v1alpha1.ApplyResultApplied))
+		Expect(status.Resources[1].Health).To(Equal(v1alpha1.ResourceHealthProgressing))
+		Expect(status.Resources[2]).To(Equal(v1alpha1.ResourceStatus{
+			InventoryEntry: pruneInventoryEntry("resource-status-config"),
+			ApplyResult:    v1alpha1.ApplyResultApplied,
+			Health:         v1alpha1.ResourceHealthHealthy,
+		}))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, resourceStatusPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists("resource-status-config")).To(BeFalse())
+	})
+})
+
+func getSampleStatus(sampleObjKey client.ObjectKey) v1alpha1.SampleStatus {
+	sampleCR := &v1alpha1.Sample{}
+	Expect(k8sClient.Get(ctx, sampleObjKey, sampleCR)).To(Succeed())
+	return sampleCR.Status
+}
@@ -0,0 +1,95 @@
+package controllers_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	watchedConfigMapName     = "watched-config"
+	watchedConfigMapManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + watchedConfigMapName + `
+  namespace: default
+data:
+  config: value
+`
+)
+
+var _ = Describe("Sample CR is installed without resyncs", Ordered, func() {
+	sampleCR := createSampleCR("watched-sample-with-a-name-longer-than-the-sixty-three-characters-of-label-values", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	configMapKey := client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: watchedConfigMapName}
+
+	BeforeAll(func() {
+		reconciler.ResyncInterval = 0
+		DeferCleanup(func() {
+			reconciler.ResyncInterval = 3 * time.Second
+		})
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{"configmap.yaml": watchedConfigMapManifest})
+	})
+
+	It("should label the applied resources with the UID of SampleCR", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		// the last requeue of Processing state is not awaited by the following tests
+		Consistently(getCRStatus(sampleCRKey)).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, configMapKey, configMap)).To(Succeed())
+		Expect(k8sClient.Get(ctx, sampleCRKey, sampleCR)).To(Succeed())
+		Expect(configMap.GetLabels()).To(HaveKeyWithValue("operator.kyma-project.io/sample-uid",
+			string(sampleCR.GetUID())))
+	})
+
+	It("should revert changes of an applied resource", func() {
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, configMapKey, configMap)).To(Succeed())
+		configMap.Data["config"] = "changed"
+		Expect(k8sClient.Update(ctx, configMap)).To(Succeed())
+
+		Eventually(func(g Gomega) string {
+			g.Expect(k8sClient.Get(ctx, configMapKey, configMap)).To(Succeed())
+			return configMap.Data["config"]
+		}).
+			WithTimeout(10 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal("value"))
+	})
+
+	It("should recreate a deleted resource", func() {
+		Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: configMapKey.Namespace, Name: configMapKey.Name,
+		}})).To(Succeed())
+
+		Eventually(func() bool { return configMapExists(watchedConfigMapName) }).
+			WithTimeout(10 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+
+	It("should delete SampleCR", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists(watchedConfigMapName)).To(BeFalse())
+	})
+})
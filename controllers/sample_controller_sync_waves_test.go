@@ -0,0 +1,250 @@
+package controllers_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	syncWaveClaimName     = "sync-wave-claim"
+	syncWaveConfigMapName = "sync-wave-config"
+	consumerStorageClass  = "wait-for-first-consumer"
+	consumerClaimName     = "first-consumer-claim"
+	consumerConfigMapName = "first-consumer-config"
+	consumerManifest      = `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: ` + consumerClaimName + `
+  namespace: default
+  annotations:
+    operator.kyma-project.io/sync-wave: "-1"
+spec:
+  storageClassName: ` + consumerStorageClass + `
+  accessModes:
+  - ReadWriteOnce
+  resources:
+    requests:
+      storage: 1Gi
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + consumerConfigMapName + `
+  namespace: default
+  annotations:
+    operator.kyma-project.io/sync-wave: "1"
+data:
+  config: value
+`
+	syncWaveManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + syncWaveConfigMapName + `
+  namespace: default
+  annotations:
+    operator.kyma-project.io/sync-wave: "1"
+data:
+  config: value
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: ` + syncWaveClaimName + `
+  namespace: default
+  annotations:
+    operator.kyma-project.io/sync-wave: "-1"
+spec:
+  accessModes:
+  - ReadWriteOnce
+  resources:
+    requests:
+      storage: 1Gi
+`
+)
+
+var _ = Describe("Sample CR is created with resources in sync waves", Ordered, func() {
+	sampleCR := createSampleCR("sync-wave-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	claimKey := client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: syncWaveClaimName}
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{"resources.yaml": syncWaveManifest})
+	})
+
+	It("should not apply later waves while the earlier wave is not healthy", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(func() *int32 { return getSampleStatus(sampleCRKey).SyncWave }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(ptr.To[int32](-1)))
+		Expect(k8sClient.Get(ctx, claimKey, &corev1.PersistentVolumeClaim{})).To(Succeed())
+		Consistently(func() bool { return configMapExists(syncWaveConfigMapName) }).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+
+		status := getSampleStatus(sampleCRKey)
+		// a claim is progressing while it is pending within its grace period
+		Expect(status.State).To(Equal(v1alpha1.StateProcessing))
+		Expect(status.ResourcesReady).To(Equal("0/2"))
+		Expect(status.Resources).To(ContainElement(HaveField("ApplyResult", v1alpha1.ApplyResultPending)))
+	})
+
+	It("should apply the next wave and set state to Ready once the earlier wave is healthy", func() {
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(k8sClient.Get(ctx, claimKey, claim)).To(Succeed())
+		claim.Status.Phase = corev1.ClaimBound
+		Expect(k8sClient.Status().Update(ctx, claim)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(configMapExists(syncWaveConfigMapName)).To(BeTrue())
+		Expect(getSampleStatus(sampleCRKey).SyncWave).To(Equal(ptr.To[int32](1)))
+	})
+
+	It("should delete the later wave before the earlier wave when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+
+		// the claim keeps its protection finalizer, as there is no controller removing it in envtest
+		Eventually(func() *int32 { return getSampleStatus(sampleCRKey).SyncWave }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(ptr.To[int32](-1)))
+		Expect(configMapExists(syncWaveConfigMapName)).To(BeFalse())
+
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(k8sClient.Get(ctx, claimKey, claim)).To(Succeed())
+		Expect(claim.GetDeletionTimestamp().IsZero()).To(BeFalse())
+		claim.SetFinalizers(nil)
+		Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+var _ = Describe("Sample CR is created with an invalid sync wave", Ordered, func() {
+	sampleCR := createSampleCR("invalid-sync-wave-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	It("should end in Error state pointing to the invalid annotation", func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: invalid-sync-wave-config
+  namespace: default
+  annotations:
+    operator.kyma-project.io/sync-wave: first
+`,
+		})
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+		condition := getInstallCondition(sampleCRKey)
+		Expect(condition.Reason).To(Equal(v1alpha1.ConditionReasonSyncWaveInvalid))
+		Expect(condition.Message).To(ContainSubstring("invalid-sync-wave-config"))
+		Expect(configMapExists("invalid-sync-wave-config")).To(BeFalse())
+
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+var _ = Describe("Sample CR is created with a claim waiting for its first consumer", Ordered, func() {
+	sampleCR := createSampleCR("first-consumer-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	claimKey := client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: consumerClaimName}
+
+	BeforeAll(func() {
+		storageClass := &storagev1.StorageClass{
+			ObjectMeta:        metav1.ObjectMeta{Name: consumerStorageClass},
+			Provisioner:       "kubernetes.io/no-provisioner",
+			VolumeBindingMode: ptr.To(storagev1.VolumeBindingWaitForFirstConsumer),
+		}
+		Expect(k8sClient.Create(ctx, storageClass)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(k8sClient.Delete(ctx, storageClass)).To(Succeed())
+		})
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{"resources.yaml": consumerManifest})
+	})
+
+	It("should apply the later waves without waiting for the claim to be bound", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(func() bool { return configMapExists(consumerConfigMapName) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(func() *int32 { return getSampleStatus(sampleCRKey).SyncWave }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(ptr.To[int32](1)))
+
+		status := getSampleStatus(sampleCRKey)
+		Expect(status.State).To(Equal(v1alpha1.StateProcessing))
+		Expect(status.Resources).To(ContainElement(And(
+			HaveField("Name", consumerClaimName),
+			HaveField("Health", v1alpha1.ResourceHealthProgressing),
+			HaveField("Message", ContainSubstring("first consumer")),
+		)))
+	})
+
+	It("should set state to Ready once the claim is bound", func() {
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(k8sClient.Get(ctx, claimKey, claim)).To(Succeed())
+		claim.Status.Phase = corev1.ClaimBound
+		Expect(k8sClient.Status().Update(ctx, claim)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should delete SampleCR", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+
+		// the claim keeps its protection finalizer, as there is no controller removing it in envtest
+		Eventually(func() bool {
+			claim := &corev1.PersistentVolumeClaim{}
+			Expect(k8sClient.Get(ctx, claimKey, claim)).To(Succeed())
+			return claim.GetDeletionTimestamp().IsZero()
+		}).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeFalse())
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(k8sClient.Get(ctx, claimKey, claim)).To(Succeed())
+		claim.SetFinalizers(nil)
+		Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Expect(configMapExists(consumerConfigMapName)).To(BeFalse())
+	})
+})
@@ -0,0 +1,132 @@
+package controllers_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	urlSourcePodName  = "busybox-url-pod"
+	urlSourceUsername = "sample"
+	urlSourcePassword = "secret-password"
+)
+
+var _ = Describe("Sample CR is created with a manifest fetched from a URL", Ordered, func() {
+	manifest := []byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: ` + urlSourcePodName + `
+spec:
+  containers:
+  - name: busybox
+    image: busybox:1.36
+`)
+	var requests atomic.Int32
+	var server *httptest.Server
+
+	authSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "url-source-auth", Namespace: metav1.NamespaceDefault},
+		Data: map[string][]byte{
+			"username": []byte(urlSourceUsername),
+			"password": []byte(urlSourcePassword),
+		},
+	}
+
+	BeforeAll(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if username, password, ok := r.BasicAuth(); !ok ||
+				username != urlSourceUsername || password != urlSourcePassword {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write(manifest)
+		}))
+		DeferCleanup(server.Close)
+		Expect(k8sClient.Create(ctx, authSecret)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, authSecret)
+	})
+
+	It("should end in Error state when the digest does not match", func() {
+		sampleCR := createURLSampleCR("url-mismatch-sample", server.URL+"/manifest.yaml",
+			sha256Hex([]byte("other content")), authSecret.Name)
+		sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateError, InstallConditionStatus: metav1.ConditionFalse, Err: nil}))
+		Expect(getInstallCondition(sampleCRKey).Reason).To(Equal(v1alpha1.ConditionReasonManifestDigestMismatch))
+		err := k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: urlSourcePodName},
+			&corev1.Pod{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, urlSourcePodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+
+	It("should install the manifest with a matching digest and serve it from the cache afterwards", func() {
+		sampleCR := createURLSampleCR("url-sample", server.URL+"/manifest.yaml", sha256Hex(manifest), authSecret.Name)
+		sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+		requests.Store(0)
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getPod(metav1.NamespaceDefault, urlSourcePodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+
+		source := getSourceStatus(sampleCRKey)
+		Expect(source.Type).To(Equal("URL"))
+		Expect(source.Digest).To(Equal("sha256:" + sha256Hex(manifest)))
+
+		// the Ready state is reconciled periodically, the manifest must not be fetched again
+		Consistently(requests.Load).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(int32(1)))
+
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, urlSourcePodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+func createURLSampleCR(sampleName, url, digest, authSecretName string) *v1alpha1.Sample {
+	sampleCR := createSampleCR(sampleName, "")
+	sampleCR.Spec.Source = &v1alpha1.ManifestSource{URL: &v1alpha1.URLSource{
+		URL:            url,
+		SHA256:         digest,
+		AuthSecretName: authSecretName,
+	}}
+	return sampleCR
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,187 @@
+package controllers_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	warningPodName   = "busybox-warning-pod"
+	warningClaimName = "warning-claim"
+)
+
+var _ = Describe("Sample CR is installed with a pod which becomes unhealthy", Ordered, func() {
+	sampleCR := createSampleCR("warning-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+
+	BeforeAll(func() {
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: ` + warningPodName + `
+  namespace: default
+spec:
+  containers:
+  - name: busybox
+    image: busybox:1.36
+`,
+		})
+	})
+
+	It("should set state to Ready once the pod is ready", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(getPod(metav1.NamespaceDefault, warningPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should set state to Warning when the pod is not ready anymore", func() {
+		setWarningPodReady(corev1.ConditionFalse)
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateWarning, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		condition := getInstallCondition(sampleCRKey)
+		Expect(condition.Reason).To(Equal(v1alpha1.ConditionReasonResourcesDegraded))
+		Expect(condition.Message).To(ContainSubstring(warningPodName))
+	})
+
+	It("should return to Ready state when the pod is ready again", func() {
+		setWarningPodReady(corev1.ConditionTrue)
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		Expect(getInstallCondition(sampleCRKey).Reason).To(Equal(v1alpha1.ConditionReasonReady))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		Eventually(checkDeleted(sampleCRKey, metav1.NamespaceDefault, warningPodName)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+var _ = Describe("Sample CR is installed for the first time with a claim which is not bound", Ordered, func() {
+	sampleCR := createSampleCR("warning-claim-sample", "")
+	sampleCRKey := client.ObjectKeyFromObject(sampleCR)
+	claimKey := client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: warningClaimName}
+
+	BeforeAll(func() {
+		reconciler.ReadinessTimeout = 2 * time.Second
+		DeferCleanup(func() {
+			reconciler.ReadinessTimeout = 0
+		})
+		sampleCR.Spec.ResourceFilePath = createManifestDir(map[string]string{
+			"claim.yaml": `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: ` + warningClaimName + `
+  namespace: default
+spec:
+  accessModes:
+  - ReadWriteOnce
+  resources:
+    requests:
+      storage: 1Gi
+`,
+		})
+	})
+
+	It("should wait for the claim to be bound within its grace period", func() {
+		Expect(k8sClient.Create(ctx, sampleCR)).To(Succeed())
+
+		Eventually(func() []v1alpha1.ResourceStatus { return getSampleStatus(sampleCRKey).Resources }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(ContainElement(And(
+				HaveField("Name", warningClaimName),
+				HaveField("Health", v1alpha1.ResourceHealthProgressing),
+			)))
+	})
+
+	It("should set state to Warning instead of ReadinessTimeoutState once the claim lost its volume", func() {
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(k8sClient.Get(ctx, claimKey, claim)).To(Succeed())
+		claim.Status.Phase = corev1.ClaimLost
+		Expect(k8sClient.Status().Update(ctx, claim)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateWarning, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		// the readiness timeout does not apply to resources which need user action
+		Consistently(getCRStatus(sampleCRKey)).
+			WithTimeout(5 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateWarning, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+		condition := getInstallCondition(sampleCRKey)
+		Expect(condition.Reason).To(Equal(v1alpha1.ConditionReasonResourcesDegraded))
+		Expect(condition.Message).To(ContainSubstring(warningClaimName))
+	})
+
+	It("should set state to Ready once the claim is bound", func() {
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(k8sClient.Get(ctx, claimKey, claim)).To(Succeed())
+		claim.Status.Phase = corev1.ClaimBound
+		Expect(k8sClient.Status().Update(ctx, claim)).To(Succeed())
+
+		Eventually(getCRStatus(sampleCRKey)).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(Equal(CRStatus{State: v1alpha1.StateReady, InstallConditionStatus: metav1.ConditionTrue, Err: nil}))
+	})
+
+	It("should delete installed resources when SampleCR is deleted", func() {
+		Expect(k8sClient.Delete(ctx, sampleCR)).To(Succeed())
+		// there is no controller removing the protection finalizer of claims in envtest
+		Eventually(func(g Gomega) bool {
+			claim := &corev1.PersistentVolumeClaim{}
+			err := k8sClient.Get(ctx, claimKey, claim)
+			if errors.IsNotFound(err) {
+				return true
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			if !claim.GetDeletionTimestamp().IsZero() {
+				claim.SetFinalizers(nil)
+				g.Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+			}
+			return false
+		}).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+		Eventually(func() bool { return errors.IsNotFound(k8sClient.Get(ctx, sampleCRKey, &v1alpha1.Sample{})) }).
+			WithTimeout(30 * time.Second).
+			WithPolling(500 * time.Millisecond).
+			Should(BeTrue())
+	})
+})
+
+func setWarningPodReady(status corev1.ConditionStatus) {
+	pod := &corev1.Pod{}
+	Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: warningPodName}, pod)).
+		To(Succeed())
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+	Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+}
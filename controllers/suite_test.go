@@ -18,6 +18,7 @@ package controllers_test
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -29,6 +30,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
@@ -78,7 +80,7 @@ var _ = BeforeSuite(func() {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases"), filepath.Join("..", "crd")},
 		ErrorIfCRDPathMissing: true,
 	}
 
@@ -105,16 +107,32 @@ var _ = BeforeSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 
 	reconciler = &controllers.SampleReconciler{
-		Client:             k8sManager.GetClient(),
-		Scheme:             scheme.Scheme,
-		EventRecorder:      k8sManager.GetEventRecorderFor("tests"),
-		FinalState:         operatorkymaprojectiov1alpha1.StateReady,
-		FinalDeletionState: operatorkymaprojectiov1alpha1.StateDeleting,
+		Client:                  k8sManager.GetClient(),
+		Scheme:                  scheme.Scheme,
+		EventRecorder:           k8sManager.GetEventRecorderFor("tests"),
+		FinalState:              operatorkymaprojectiov1alpha1.StateReady,
+		FinalDeletionState:      operatorkymaprojectiov1alpha1.StateDeleting,
+		ManifestCacheDir:        GinkgoT().TempDir(),
+		GitFileRoot:             os.TempDir(),
+		ReadinessTimeoutState:   operatorkymaprojectiov1alpha1.StateError,
+		ResyncInterval:          3 * time.Second,
+		MaxConcurrentReconciles: 4,
+		MaxConcurrentApplies:    4,
+		DeletionBlockingKinds: []schema.GroupVersionKind{
+			operatorkymaprojectiov1alpha1.GroupVersion.WithKind("ThirdParty"),
+		},
 	}
 
 	err = reconciler.SetupWithManager(k8sManager, rateLimiter)
 	Expect(err).ToNot(HaveOccurred())
 
+	err = (&controllers.ManagedReconciler{
+		Client:        k8sManager.GetClient(),
+		Scheme:        scheme.Scheme,
+		EventRecorder: k8sManager.GetEventRecorderFor("tests"),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
 	go func() {
 		defer GinkgoRecover()
 		err = k8sManager.Start(ctx)
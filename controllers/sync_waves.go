@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+// syncWaveAnnotation assigns a resource to a sync wave, resources without it belong to wave 0.
+// Waves are applied in ascending order, a wave is only applied once all resources of the earlier waves are healthy.
+const syncWaveAnnotation = "operator.kyma-project.io/sync-wave"
+
+var errSyncWaveInvalid = errors.New("sync wave is invalid")
+
+// syncWave is a group of resources which are applied together.
+type syncWave struct {
+	number int32
+	objs   []*unstructured.Unstructured
+}
+
+// syncWaveOf returns the sync wave of obj, resources with an invalid annotation belong to wave 0,
+// see validateSyncWaves.
+func syncWaveOf(obj *unstructured.Unstructured) int32 {
+	wave, _ := parseSyncWave(obj)
+	return wave
+}
+
+func parseSyncWave(obj *unstructured.Unstructured) (int32, error) {
+	value, found := obj.GetAnnotations()[syncWaveAnnotation]
+	if !found {
+		return 0, nil
+	}
+	wave, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s has annotation %s: %q, which is not an integer",
+			errSyncWaveInvalid, inventoryEntryOf(obj), syncWaveAnnotation, value)
+	}
+	return int32(wave), nil
+}
+
+// validateSyncWaves returns an error for the first resource of objs with an invalid sync wave annotation.
+func validateSyncWaves(objs []*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		if _, err := parseSyncWave(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncWavesOf groups objs sorted by sortByApplyOrder into their sync waves.
+func syncWavesOf(objs []*unstructured.Unstructured) []syncWave {
+	waves := make([]syncWave, 0)
+	for _, obj := range objs {
+		number := syncWaveOf(obj)
+		if len(waves) == 0 || waves[len(waves)-1].number != number {
+			waves = append(waves, syncWave{number: number})
+		}
+		waves[len(waves)-1].objs = append(waves[len(waves)-1].objs, obj)
+	}
+	return waves
+}
+
+// isSyncWaveHealthy reports whether all resources of a wave were applied and none of them is still progressing
+// or degraded, so the next wave can be applied. Resources with an unknown health are not waited for.
+func isSyncWaveHealthy(resources []v1alpha1.ResourceStatus) bool {
+	for _, resource := range resources {
+		if resource.ApplyResult != v1alpha1.ApplyResultApplied ||
+			resource.Health == v1alpha1.ResourceHealthProgressing || resource.Health == v1alpha1.ResourceHealthDegraded {
+			return false
+		}
+	}
+	return true
+}
+
+func pendingResourceStatus(obj *unstructured.Unstructured, wave int32) v1alpha1.ResourceStatus {
+	return v1alpha1.ResourceStatus{
+		InventoryEntry: inventoryEntryOf(obj),
+		ApplyResult:    v1alpha1.ApplyResultPending,
+		Health:         v1alpha1.ResourceHealthUnknown,
+		Message:        fmt.Sprintf("waiting for the resources of sync wave %d to become healthy", wave),
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kyma-project/template-operator/api/v1alpha1"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	authUsernameKey    = "username"
+	authPasswordKey    = "password"
+	authTokenKey       = "token"
+)
+
+//nolint:gochecknoglobals
+var defaultHTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+
+// getResourcesFromURL returns the resources of the manifest served at spec.source.url in unstructured format.
+// Content is only installed if it matches the pinned SHA256 digest. Once fetched, it is served from the
+// content cache, so the manifest is not fetched again as long as the digest stays the same.
+func (r *SampleReconciler) getResourcesFromURL(ctx context.Context,
+	objectInstance *v1alpha1.Sample,
+) (*ManifestResources, error) {
+	urlSource := objectInstance.Spec.Source.URL
+	cache := contentCache{dir: r.ManifestCacheDir}
+	content, cached, err := cache.get(urlSource.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cached {
+		content, err = r.fetchManifest(ctx, objectInstance.GetNamespace(), urlSource)
+		if err != nil {
+			return nil, err
+		}
+		if digest := sha256Digest(content); digest != urlSource.SHA256 {
+			return nil, fmt.Errorf("%w: content served at %s has digest sha256:%s, expected sha256:%s",
+				errManifestDigestMismatch, redactURL(urlSource.URL), digest, urlSource.SHA256)
+		}
+		if err = cache.put(urlSource.SHA256, content); err != nil {
+			// the manifest can still be installed, it is fetched again with the next reconciliation
+			log.FromContext(ctx).Error(err, "error caching manifest")
+		}
+	}
+
+	manifest, err := decompressManifest(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s could not be decompressed: %w",
+			errManifestParse, redactURL(urlSource.URL), err)
+	}
+	resources := parseManifestStringToObjects(redactURL(urlSource.URL), string(manifest))
+	resources.Source = v1alpha1.SourceStatus{Type: string(sourceTypeURL), Digest: "sha256:" + urlSource.SHA256}
+	return resources, r.setDefaultNamespace(resources, objectInstance.GetNamespace())
+}
+
+// fetchManifest fetches the content served at the URL of urlSource, authenticated with the credentials
+// of its auth Secret, if configured.
+func (r *SampleReconciler) fetchManifest(ctx context.Context, namespace string,
+	urlSource *v1alpha1.URLSource,
+) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlSource.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request for %s could not be created: %w", redactURL(urlSource.URL), err)
+	}
+	if urlSource.AuthSecretName != "" {
+		secretKey := client.ObjectKey{Namespace: namespace, Name: urlSource.AuthSecretName}
+		if err = r.setAuthHeader(ctx, req, secretKey); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("manifest could not be fetched: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s returned %s, check spec.source.url",
+			errManifestNotFound, req.URL.Redacted(), resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("manifest could not be fetched from %s: %s", req.URL.Redacted(), resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("manifest could not be read from %s: %w", req.URL.Redacted(), err)
+	}
+	if len(content) > maxManifestSize {
+		return nil, fmt.Errorf("manifest served at %s exceeds %d bytes", req.URL.Redacted(), maxManifestSize)
+	}
+	return content, nil
+}
+
+// setAuthHeader authenticates req with the token of the auth Secret as bearer token,
+// or with its username and password for basic auth.
+func (r *SampleReconciler) setAuthHeader(ctx context.Context, req *http.Request, secretKey client.ObjectKey) error {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		return fmt.Errorf("auth secret %s could not be read: %w", secretKey, err)
+	}
+
+	if token, found := secret.Data[authTokenKey]; found {
+		req.Header.Set("Authorization", "Bearer "+string(token))
+		return nil
+	}
+	username, found := secret.Data[authUsernameKey]
+	if !found {
+		return fmt.Errorf("auth secret %s contains neither a %s nor a %s key",
+			secretKey, authTokenKey, authUsernameKey)
+	}
+	req.SetBasicAuth(string(username), string(secret.Data[authPasswordKey]))
+	return nil
+}
+
+// redactURL returns rawURL with the password of its user info redacted.
+func redactURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsedURL.Redacted()
+}
@@ -20,6 +20,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -44,6 +45,10 @@ const (
 	rateLimiterFrequencyDefault = 30
 	failureBaseDelayDefault     = 1 * time.Second
 	failureMaxDelayDefault      = 1000 * time.Second
+	readinessTimeoutDefault     = 10 * time.Minute
+	hookTimeoutDefault          = 5 * time.Minute
+	resyncIntervalDefault       = 10 * time.Minute
+	maxConcurrentAppliesDefault = 4
 	operatorName                = "template-operator"
 )
 
@@ -53,16 +58,26 @@ var (
 )
 
 type FlagVar struct {
-	metricsAddr          string
-	enableLeaderElection bool
-	probeAddr            string
-	failureBaseDelay     time.Duration
-	failureMaxDelay      time.Duration
-	rateLimiterFrequency int
-	rateLimiterBurst     int
-	finalState           string
-	finalDeletionState   string
-	printVersion         bool
+	metricsAddr             string
+	enableLeaderElection    bool
+	probeAddr               string
+	failureBaseDelay        time.Duration
+	failureMaxDelay         time.Duration
+	rateLimiterFrequency    int
+	rateLimiterBurst        int
+	finalState              string
+	finalDeletionState      string
+	manifestCacheDir        string
+	gitFileRoot             string
+	readinessTimeout        time.Duration
+	readinessTimeoutState   string
+	hookTimeout             time.Duration
+	resyncInterval          time.Duration
+	maxConcurrentReconciles int
+	maxConcurrentApplies    int
+	deletionBlockingKinds   string
+	healthRulesFile         string
+	printVersion            bool
 }
 
 func init() { //nolint:gochecknoinits
@@ -116,16 +131,48 @@ func main() {
 		os.Exit(1)
 	}
 
+	var healthRules []v1alpha1.HealthRule
+	if flagVar.healthRulesFile != "" {
+		if healthRules, err = controllers.LoadHealthRules(flagVar.healthRulesFile); err != nil {
+			setupLog.Error(err, "unable to load health rules")
+			os.Exit(1)
+		}
+	}
+
+	deletionBlockingKinds, err := controllers.ParseGroupVersionKinds(flagVar.deletionBlockingKinds)
+	if err != nil {
+		setupLog.Error(err, "unable to parse deletion blocking kinds")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.SampleReconciler{
-		Client:             mgr.GetClient(),
-		Scheme:             mgr.GetScheme(),
-		EventRecorder:      mgr.GetEventRecorderFor(operatorName),
-		FinalState:         v1alpha1.State(flagVar.finalState),
-		FinalDeletionState: v1alpha1.State(flagVar.finalDeletionState),
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		EventRecorder:           mgr.GetEventRecorderFor(operatorName),
+		FinalState:              v1alpha1.State(flagVar.finalState),
+		FinalDeletionState:      v1alpha1.State(flagVar.finalDeletionState),
+		ManifestCacheDir:        flagVar.manifestCacheDir,
+		GitFileRoot:             flagVar.gitFileRoot,
+		ReadinessTimeout:        flagVar.readinessTimeout,
+		ReadinessTimeoutState:   v1alpha1.State(flagVar.readinessTimeoutState),
+		HookTimeout:             flagVar.hookTimeout,
+		DeletionBlockingKinds:   deletionBlockingKinds,
+		HealthRules:             healthRules,
+		ResyncInterval:          flagVar.resyncInterval,
+		MaxConcurrentReconciles: flagVar.maxConcurrentReconciles,
+		MaxConcurrentApplies:    flagVar.maxConcurrentApplies,
 	}).SetupWithManager(mgr, rateLimiter); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Sample")
 		os.Exit(1)
 	}
+	if err = (&controllers.ManagedReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor(operatorName),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Managed")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -163,6 +210,33 @@ func defineFlagVar() *FlagVar {
 		"Customize final state, to mimic state behaviour like Ready, Warning")
 	flag.StringVar(&flagVar.finalDeletionState, "final-deletion-state", string(v1alpha1.StateDeleting),
 		"Customize final state when module marked for deletion, to mimic state behaviour like Ready, Warning")
+	flag.StringVar(&flagVar.manifestCacheDir, "manifest-cache-dir",
+		filepath.Join(os.TempDir(), "template-operator-manifests"),
+		"Directory remote manifests are cached in and git repositories are checked out to. "+
+			"Set to an empty value to disable caching.")
+	// go-git does not accept a transport per fetch, so file:// remotes are served by a transport which is
+	// installed for the whole process, see controllers.SampleReconciler.GitFileRoot.
+	flag.StringVar(&flagVar.gitFileRoot, "git-file-root", "",
+		"Directory file:// git remotes are served from. Without a directory, file:// remotes are rejected. "+
+			"The directory applies to all git operations of the operator process.")
+	flag.DurationVar(&flagVar.readinessTimeout, "readiness-timeout", readinessTimeoutDefault,
+		"Time the installed resources have to become ready, set to 0 to wait indefinitely")
+	flag.StringVar(&flagVar.readinessTimeoutState, "readiness-timeout-state", string(v1alpha1.StateError),
+		"State set when the installed resources did not become ready in time, like Error or Warning")
+	flag.DurationVar(&flagVar.hookTimeout, "hook-timeout", hookTimeoutDefault,
+		"Time the hooks of a phase have to complete, set to 0 to wait indefinitely")
+	flag.DurationVar(&flagVar.resyncInterval, "resync-interval", resyncIntervalDefault,
+		"Interval installed Samples are reconciled in besides changes of their resources, set to 0 to disable")
+	flag.IntVar(&flagVar.maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"Maximum number of Samples reconciled at the same time")
+	flag.IntVar(&flagVar.maxConcurrentApplies, "max-concurrent-applies", maxConcurrentAppliesDefault,
+		"Maximum number of resources of a Sample applied at the same time, within a sync wave and kind phase")
+	flag.StringVar(&flagVar.deletionBlockingKinds, "deletion-blocking-kinds",
+		"ThirdParty.v1alpha1.operator.kyma-project.io",
+		"Comma separated list of kinds as Kind.version.group, Samples are not deleted while resources of these "+
+			"kinds exist which were not installed by them")
+	flag.StringVar(&flagVar.healthRulesFile, "health-rules-file", "",
+		"YAML file with a list of health rules applying to the resources of all Samples")
 	flag.BoolVar(&flagVar.printVersion, "version", false, "Prints the operator version and exits")
 	return flagVar
 }